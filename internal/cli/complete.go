@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// completeSlot returns the completion for commands taking a slot followed by key=value arguments:
+// the names of the slots, then the variables of the slot as key=, and after key= the choices of the variable.
+func completeSlot(config *string) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		store, err := newStore(cmd, *config)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		slots, err := loadSlots(cmd, store)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		if len(args) == 0 {
+			completions := make([]cobra.Completion, 0, len(slots))
+
+			for _, slot := range slots {
+				completions = append(completions, cobra.CompletionWithDesc(slot.Name, slot.Description))
+			}
+
+			return completions, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		if !slots.Exists(args[0]) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		if key, _, found := strings.Cut(toComplete, "="); found {
+			return completeValues(cmd, slots.Get(args[0]), key)
+		}
+
+		return completeKeys(slots.Get(args[0]), args[1:])
+	}
+}
+
+// completeKeys completes the variables of the slot that are neither built in nor given in withs as key=.
+func completeKeys(selected *slot.Slot, withs []string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	variables, err := selected.Variables(isBuiltin)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	given := make([]string, 0, len(withs))
+
+	for _, with := range withs {
+		key, _, _ := strings.Cut(with, "=")
+		key, _ = splitOperator(key)
+		given = append(given, key)
+	}
+
+	var completions []cobra.Completion
+
+	for _, variable := range variables {
+		if variable.Builtin || slices.Contains(given, variable.Name) {
+			continue
+		}
+
+		completions = append(completions, cobra.CompletionWithDesc(variable.Name+"=", variable.Description))
+	}
+
+	return completions, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeValues completes key=value for the choices of the variable named by key,
+// or true and false for boolean parameters. Commands generating choices are refused with --no-exec.
+// Key may end in an operator: key@= completes files, and key:= completes the choices as JSON.
+func completeValues(cmd *cobra.Command, selected *slot.Slot, key string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	name, operator := splitOperator(key)
+	if operator == withFile {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	param := selected.Params.Get(name)
+	if param == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	noExec, _ := cmd.Flags().GetBool("no-exec")
+
+	choices, err := param.Choices.List(selected.Runner(cmd.Context(), noExec))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	if len(choices) == 0 && param.Type == slot.TypeBool {
+		choices = []string{"true", "false"}
+	}
+
+	completions := make([]cobra.Completion, 0, len(choices))
+
+	for _, choice := range choices {
+		if operator == withJSON && param.Type != slot.TypeBool && param.Type != slot.TypeInt {
+			encoded, err := json.Marshal(choice)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			choice = string(encoded)
+		}
+
+		completions = append(completions, key+"="+choice)
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
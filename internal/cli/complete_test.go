@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompleteValues(t *testing.T) {
+	const slots = `slots:
+  - name: deploy
+    cmd: 'deploy {{.env}} {{.debug}}'
+    params:
+      - name: env
+        choices: [dev, prod]
+      - name: debug
+        type: bool
+`
+
+	tests := []struct {
+		toComplete string
+		want       []string
+		directive  string
+	}{
+		{"env=", []string{"env=dev", "env=prod"}, ":4"},
+		{"env+=", []string{"env+=dev", "env+=prod"}, ":4"},
+		{"env:=", []string{`env:="dev"`, `env:="prod"`}, ":4"},
+		{"debug:=", []string{"debug:=true", "debug:=false"}, ":4"},
+		// Files are completed by the shell.
+		{"env@=", []string{}, ":0"},
+	}
+
+	for _, test := range tests {
+		output, err := runSlot(t, slots, "__complete", "render", "deploy", test.toComplete)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The last line is the directive.
+		lines := strings.Split(output, "\n")
+		got, directive := lines[:len(lines)-1], lines[len(lines)-1]
+
+		if !slices.Equal(got, test.want) || directive != test.directive {
+			t.Errorf("%s: got %q %s, want %q %s", test.toComplete, got, directive, test.want, test.directive)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+)
+
+// Copy returns the cobra command for duplicating a slot.
+func Copy(config *string) *cobra.Command {
+	var to string
+
+	cmd := &cobra.Command{
+		Use:   "copy <slot> <new-name>",
+		Short: "Duplicate a slot",
+		Long: heredoc.Doc(`
+			Duplicate a slot under a new name, as the basis for a variant.
+
+			The copy is added to the file that defines the original, or to the file given
+			with --to, which must be the slots file or one of its includes.
+			The new name must not be used by any slot in the slots file or its includes.
+			Copies into a named include get its namespace, e.g. 'team:deploy-staging'.
+		`),
+		Example: heredoc.Doc(`
+			# Copy 'deploy' to 'deploy-staging'
+			slot copy deploy deploy-staging
+
+			# Copy 'deploy' into a shared included file
+			slot copy deploy deploy-team --to ./team/slots.yaml
+		`),
+		Aliases: []string{"cp"},
+		Args:    cobra.ExactArgs(2), //nolint:mnd   // Clear from the context
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := newStore(cmd, *config)
+			if err != nil {
+				return err
+			}
+
+			all, err := loadAll(cmd, store)
+			if err != nil {
+				return err
+			}
+
+			name, newName := args[0], args[1]
+
+			copied, err := lookup(all.Unique(), name)
+			if err != nil {
+				return err
+			}
+
+			target := copied.Source
+			if to != "" {
+				target = to
+			}
+
+			if newName, err = store.Qualify(target, newName); err != nil {
+				return err
+			}
+
+			if err := checkFree(all, newName); err != nil {
+				return err
+			}
+
+			copied.Name = newName
+
+			if err := store.Insert(target, copied); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "copied %q to %q\n", name, newName)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "file to add the copy to (default: the file defining the slot)")
+
+	return cmd
+}
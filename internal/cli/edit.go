@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/slot/internal/render"
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// Edit returns the cobra command for editing a slot in an editor.
+func Edit(config *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit <slot>",
+		Short: "Edit a slot in $EDITOR",
+		Long: heredoc.Doc(`
+			Open a slot in $VISUAL or $EDITOR as YAML and save the result back to
+			the file that defines it.
+
+			The edited slot is validated before saving. On errors, the editor is reopened
+			with the error shown as a comment. Save an empty file to cancel.
+		`),
+		Example: heredoc.Doc(`
+			# Edit the 'deploy' slot
+			slot edit deploy
+
+			# Use a specific editor
+			EDITOR=nano slot edit deploy
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := newStore(cmd, *config)
+			if err != nil {
+				return err
+			}
+
+			slots, err := loadSlots(cmd, store)
+			if err != nil {
+				return err
+			}
+
+			if len(slots) == 0 {
+				return errors.New("no slots to edit")
+			}
+
+			name := args[0]
+			if !slots.Exists(name) {
+				return fmt.Errorf("no such slot %q: did you mean %q?", name, slots.Closest(name))
+			}
+
+			edited, err := editSlot(cmd, *slots.Get(name), func(edited slot.Slot) error {
+				return validateEdit(slots, name, edited)
+			})
+			if err != nil {
+				return err
+			}
+
+			if edited == nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), "edit cancelled")
+
+				return nil
+			}
+
+			replaced, err := store.Replace(name, *edited)
+			if err != nil {
+				return err
+			}
+
+			if !replaced {
+				return fmt.Errorf("slot %q was removed while editing", name)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "saved %q\n", edited.Name)
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// editSlot opens the slot in an editor until the result passes validate.
+// It returns nil if the user saved an empty file.
+func editSlot(cmd *cobra.Command, original slot.Slot, validate func(slot.Slot) error) (*slot.Slot, error) {
+	content, err := yaml.MarshalWithOptions(
+		original,
+		yaml.IndentSequence(true),
+		yaml.UseLiteralStyleIfMultiline(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling slot: %w", err)
+	}
+
+	file, err := os.CreateTemp("", "slot-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.Remove(file.Name())
+
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	header := fmt.Sprintf("Editing slot %q. Save an empty file to cancel.", original.Name)
+
+	for {
+		if err := os.WriteFile(file.Name(), withComment(header, content), 0o600); err != nil {
+			return nil, err
+		}
+
+		if err := runEditor(cmd, file.Name()); err != nil {
+			return nil, err
+		}
+
+		data, err := os.ReadFile(file.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		content = withoutComment(data)
+		if len(bytes.TrimSpace(content)) == 0 {
+			return nil, nil //nolint:nilnil	// nil signals a cancelled edit
+		}
+
+		var edited slot.Slot
+
+		err = yaml.Unmarshal(content, &edited)
+		if err == nil {
+			err = validate(edited)
+		}
+
+		if err == nil {
+			return &edited, nil
+		}
+
+		header = fmt.Sprintf("Error: %v\nFix the slot below or save an empty file to cancel.", err)
+	}
+}
+
+// validateEdit checks that an edited slot can be saved in place of the slot named name.
+func validateEdit(slots slot.Slots, name string, edited slot.Slot) error {
+	if edited.Name == "" {
+		return errors.New("slot name is empty")
+	}
+
+	if edited.Name != name && slots.Exists(edited.Name) {
+		return fmt.Errorf("slot %q already exists", edited.Name)
+	}
+
+	if _, err := render.Parse(edited.Cmd); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	if _, err := edited.Referenced(); err != nil {
+		return fmt.Errorf("invalid template in dir or env: %w", err)
+	}
+
+	if err := edited.Validate(); err != nil {
+		return fmt.Errorf("invalid vars: %w", err)
+	}
+
+	return nil
+}
+
+// runEditor opens path in $VISUAL, $EDITOR or a platform default, attached to the terminal.
+func runEditor(cmd *cobra.Command, path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	if editor == "" {
+		editor = "vi"
+
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	fields := strings.Fields(editor)
+
+	//nolint:gosec	// The editor is chosen by the user.
+	process := exec.CommandContext(cmd.Context(), fields[0], append(fields[1:], path)...)
+	process.Stdin = os.Stdin
+	process.Stdout = os.Stdout
+	process.Stderr = os.Stderr
+
+	if err := process.Run(); err != nil {
+		return fmt.Errorf("running editor %q: %w", editor, err)
+	}
+
+	return nil
+}
+
+// commentPrefix marks the lines added to the top of the edited file.
+const commentPrefix = "# slot: "
+
+// withComment prepends each line of comment to content as a YAML comment.
+func withComment(comment string, content []byte) []byte {
+	var buffer bytes.Buffer
+
+	for line := range strings.SplitSeq(comment, "\n") {
+		buffer.WriteString(commentPrefix + line + "\n")
+	}
+
+	buffer.Write(content)
+
+	return buffer.Bytes()
+}
+
+// withoutComment strips the comment lines added by withComment.
+func withoutComment(data []byte) []byte {
+	for bytes.HasPrefix(data, []byte(commentPrefix)) {
+		_, data, _ = bytes.Cut(data, []byte("\n"))
+	}
+
+	return data
+}
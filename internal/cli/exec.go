@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/slot/internal/shell"
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// ExitError reports the exit code of a command run by slot exec.
+type ExitError struct {
+	// Code is the exit code to exit with.
+	Code int
+}
+
+// Error returns the exit status as text.
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Exec returns the cobra command for running command slots.
+func Exec(config *string) *cobra.Command {
+	var interactive bool
+
+	cmd := &cobra.Command{
+		Use:   "exec <slot> [key=value...] [-- args...]",
+		Short: "Run a slot",
+		Long: heredoc.Doc(`
+			Render a slot like 'slot render' and run it, without the shell integration.
+
+			The command runs through the slot's shell, or $SHELL, with its env and dir applied.
+			Standard input and output are passed through, and slot exits with the command's exit code.
+			Interrupt, quit, terminate and hangup signals sent to slot are forwarded to the command.
+			Interrupt and quit typed at the terminal reach the command directly and are not sent twice.
+		`),
+		Example: heredoc.Doc(`
+			# Run a slot from a script, Makefile or cron job
+			slot exec deploy file=k8s.yml
+
+			# Pass arguments to the template as CLI_ARGS
+			slot exec grep -- -i needle
+		`),
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeSlot(config),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			command, err := prepare(cmd, *config, args, interactive, true)
+			if err != nil {
+				return err
+			}
+
+			return run(cmd, command)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "prompt for template variables not given as arguments")
+
+	return cmd
+}
+
+// run runs the command through its shell, forwarding interrupt, quit, terminate and hangup signals,
+// and returns an *ExitError if it fails.
+func run(cmd *cobra.Command, command slot.Command) error {
+	process, err := shell.Command(cmd.Context(), cmp.Or(command.Shell, shell.Default()), command.Cmd)
+	if err != nil {
+		return err
+	}
+
+	process.Stdin = cmd.InOrStdin()
+	process.Stdout = cmd.OutOrStdout()
+	process.Stderr = cmd.ErrOrStderr()
+	process.Env = os.Environ()
+
+	for key, value := range command.Env {
+		process.Env = append(process.Env, key+"="+expandHome(value))
+	}
+
+	if command.Dir != "" {
+		process.Dir = expandHome(command.Dir)
+	}
+
+	// Interrupt and quit typed at the terminal reach the whole foreground process group, the command included,
+	// so slot only outlives them to report the command's exit code. All other signals are forwarded,
+	// e.g. those sent with kill, timeout or by a process supervisor.
+	signals := make(chan os.Signal, 1)
+
+	signal.Notify(signals, os.Interrupt, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	if err := process.Start(); err != nil {
+		return fmt.Errorf("running %q: %w", process.Path, err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for {
+			select {
+			case received := <-signals:
+				fromTerminal := received == os.Interrupt || received == syscall.SIGQUIT
+				if !fromTerminal || !inForeground(process.Stdin) {
+					_ = process.Process.Signal(received)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err = process.Wait()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+
+	code := exitErr.ExitCode()
+
+	// Follow the shell convention for commands terminated by a signal.
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		code = 128 + int(status.Signal())
+	}
+
+	return &ExitError{Code: code}
+}
+
+// expandHome replaces a leading ~ with the home directory, like the shell does for slot run.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(home, path[1:])
+}
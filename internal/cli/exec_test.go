@@ -0,0 +1,55 @@
+//go:build unix
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// runScript runs script through sh with run and returns its output.
+func runScript(t *testing.T, script string) (string, error) {
+	t.Helper()
+
+	var stdout bytes.Buffer
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	cmd.SetIn(strings.NewReader(""))
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&bytes.Buffer{})
+
+	err := run(cmd, slot.Command{Cmd: script, Shell: "sh"})
+
+	return stdout.String(), err
+}
+
+func TestRunForwardsTerminate(t *testing.T) {
+	_, err := runScript(t, "kill -TERM $PPID; exec sleep 5")
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 128+15 {
+		t.Errorf("got error %v, want exit status %d", err, 128+15)
+	}
+}
+
+func TestRunForwardsInterrupt(t *testing.T) {
+	// Stdin is not a terminal, so the interrupt was sent to slot alone, e.g. by kill or timeout.
+	output, err := runScript(t, "trap 'echo trapped; exit 3' INT; kill -INT $PPID; sleep 1; echo done")
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		t.Errorf("got error %v, want exit status 3", err)
+	}
+
+	if output != "trapped\n" {
+		t.Errorf("got %q, want the command to trap the interrupt", output)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package cli
+
+import "io"
+
+// inForeground reports false on platforms where the foreground process group of a terminal is not known,
+// so that every signal is forwarded.
+func inForeground(io.Reader) bool {
+	return false
+}
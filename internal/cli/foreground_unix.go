@@ -0,0 +1,33 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package cli
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// inForeground reports whether stdin is a terminal whose foreground process group is slot's own.
+// Interrupt and quit typed at such a terminal reach every process in the group, the command included.
+func inForeground(stdin io.Reader) bool {
+	file, ok := stdin.(*os.File)
+	if !ok {
+		return false
+	}
+
+	var group int32
+
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL,
+		file.Fd(),
+		uintptr(syscall.TIOCGPGRP),
+		uintptr(unsafe.Pointer(&group)), //nolint:gosec	// TIOCGPGRP writes the process group id.
+	)
+	if errno != 0 {
+		return false
+	}
+
+	return int(group) == syscall.Getpgrp()
+}
@@ -7,14 +7,16 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/idelchi/slot/internal/slot"
-	"github.com/idelchi/slot/internal/store"
 )
 
 // List returns the cobra command for listing command slots.
 func List(config *string) *cobra.Command {
 	var (
 		filterTags []string
+		output     string
+		format     string
 		tsv        bool
+		all        bool
 	)
 
 	cmd := &cobra.Command{
@@ -22,6 +24,10 @@ func List(config *string) *cobra.Command {
 		Short: "List saved slots",
 		Long: heredoc.Doc(`
 			List all saved command slots with their names, tags, and commands.
+
+			With --all, slots shadowed by an earlier slot of the same name are listed as well.
+			With --all or inside a project with a .slots.yaml file, the table shows the file
+			each slot is defined in.
 		`),
 		Example: heredoc.Doc(`
 			# List all slots in table format
@@ -32,24 +38,53 @@ func List(config *string) *cobra.Command {
 
 			# Multiple tag filters (AND logic)
 			slot list --tag k8s --tag prod
+
+			# Show which files define each slot, including shadowed definitions
+			slot list --all
+
+			# Output all fields, including variables and source files, as JSON
+			slot list --output json
+
+			# Custom output through a Go template, one line per slot
+			slot list --format '{{.Name}}: {{.Description}}'
+
+			# Custom columns aligned as a table
+			slot list --format 'table {{.Name}}\t{{join "," .Tags}}\t{{.Source}}'
 		`),
 		Aliases: []string{"ls"},
 		Args:    cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			store, err := store.New(*config)
+			store, err := newStore(cmd, *config)
 			if err != nil {
 				return err
 			}
 
-			slots, err := store.Load()
+			slots, err := loadAll(cmd, store)
 			if err != nil {
 				return err
 			}
 
+			if !all {
+				slots = slots.Unique()
+			}
+
 			slots = filterSlotsByTags(slots, filterTags)
 
+			if format != "" {
+				return slots.RenderTemplate(format, cmd.OutOrStdout())
+			}
+
 			if tsv {
-				return slots.Render("tsv", cmd.OutOrStdout())
+				output = "tsv"
+			}
+
+			render := slots.Render
+			if all || store.Project() != "" {
+				render = slots.RenderSources
+			}
+
+			if output != "table" {
+				return render(output, cmd.OutOrStdout())
 			}
 
 			// Truncate the commands if longer than 50 characters
@@ -61,12 +96,19 @@ func List(config *string) *cobra.Command {
 				}
 			}
 
-			return slots.Render("table", cmd.OutOrStdout())
+			return render("table", cmd.OutOrStdout())
 		},
 	}
 
 	cmd.Flags().StringSliceVar(&filterTags, "tags", nil, "filter by tags (repeatable)")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format (table, tsv, json, yaml)")
+	cmd.Flags().StringVar(&format, "format", "", "Go template applied to each slot ('table ' prefix aligns columns)")
 	cmd.Flags().BoolVar(&tsv, "tsv", false, "output in TSV format")
+	cmd.Flags().BoolVarP(&all, "all", "a", false, "include shadowed slots and show source files")
+
+	cmd.MarkFlagsMutuallyExclusive("format", "output", "tsv")
+
+	_ = cmd.Flags().MarkDeprecated("tsv", "use --output tsv")
 
 	return cmd
 }
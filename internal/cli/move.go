@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+)
+
+// Move returns the cobra command for moving a slot to another file.
+func Move(config *string) *cobra.Command {
+	var to string
+
+	cmd := &cobra.Command{
+		Use:   "move <slot> --to <file>",
+		Short: "Move a slot to another file",
+		Long: heredoc.Doc(`
+			Move a slot from the file that defines it into another file, which must be
+			the slots file or one of its includes.
+
+			The move is refused if another definition of the slot would take precedence
+			over it afterwards, as the first slot of a name in load order wins.
+			Moving into a named include changes the namespace of the slot, e.g. 'deploy'
+			becomes 'team:deploy'; the move is refused if that name is already used.
+		`),
+		Example: heredoc.Doc(`
+			# Move 'deploy' from the personal slots file to a shared included file
+			slot move deploy --to ./team/slots.yaml
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := newStore(cmd, *config)
+			if err != nil {
+				return err
+			}
+
+			slots, err := loadSlots(cmd, store)
+			if err != nil {
+				return err
+			}
+
+			name := args[0]
+
+			if _, err := lookup(slots, name); err != nil {
+				return err
+			}
+
+			newName, err := store.Move(name, to)
+			if err != nil {
+				return err
+			}
+
+			if newName != name {
+				fmt.Fprintf(cmd.OutOrStdout(), "moved %q to %q as %q\n", name, filepath.ToSlash(to), newName)
+
+				return nil
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "moved %q to %q\n", name, filepath.ToSlash(to))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "file to move the slot to")
+
+	_ = cmd.MarkFlagRequired("to")
+
+	return cmd
+}
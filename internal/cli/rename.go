@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// Rename returns the cobra command for renaming a slot.
+func Rename(config *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename <slot> <new-name>",
+		Short: "Rename a slot",
+		Long: heredoc.Doc(`
+			Rename the visible slot in the file that defines it.
+
+			The new name must not be used by any slot in the slots file or its includes.
+			Slots of a named include keep its namespace, so 'team:deploy' can be renamed
+			to 'team:release' or just 'release'.
+
+			If the renamed slot shadowed another definition of its old name, that definition
+			becomes visible under the old name, which is reported as a warning.
+		`),
+		Example: heredoc.Doc(`
+			# Rename 'deploy' to 'deploy-prod'
+			slot rename deploy deploy-prod
+		`),
+		Args: cobra.ExactArgs(2), //nolint:mnd   // Clear from the context
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := newStore(cmd, *config)
+			if err != nil {
+				return err
+			}
+
+			all, err := loadAll(cmd, store)
+			if err != nil {
+				return err
+			}
+
+			name, newName := args[0], args[1]
+
+			renamed, err := lookup(all.Unique(), name)
+			if err != nil {
+				return err
+			}
+
+			if newName, err = store.Qualify(renamed.Source, newName); err != nil {
+				return err
+			}
+
+			if err := checkFree(all, newName); err != nil {
+				return err
+			}
+
+			renamed.Name = newName
+
+			replaced, err := store.Replace(name, renamed)
+			if err != nil {
+				return err
+			}
+
+			if !replaced {
+				return fmt.Errorf("no such slot %q", name)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "renamed %q to %q\n", name, newName)
+
+			// The renamed slot no longer hides other definitions of its old name.
+			for _, shadow := range all.Shadows() {
+				if shadow.Slot.Name == name {
+					fmt.Fprintf(
+						cmd.ErrOrStderr(),
+						"warning: %q now refers to its definition in %q, which the renamed slot shadowed\n",
+						name,
+						filepath.ToSlash(shadow.Slot.Source),
+					)
+
+					break
+				}
+			}
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// lookup returns a copy of the slot with the given name, or an error suggesting the closest name.
+func lookup(slots slot.Slots, name string) (slot.Slot, error) {
+	if len(slots) == 0 {
+		return slot.Slot{}, errors.New("no slots found")
+	}
+
+	if !slots.Exists(name) {
+		return slot.Slot{}, fmt.Errorf("no such slot %q: did you mean %q?", name, slots.Closest(name))
+	}
+
+	return *slots.Get(name), nil
+}
+
+// checkFree returns an error if any file in the include graph defines a slot with the given name,
+// including definitions shadowed by an earlier slot of the same name.
+func checkFree(all slot.Slots, name string) error {
+	if existing := all.Get(name); existing != nil {
+		return fmt.Errorf("slot %q already exists in %q", name, filepath.ToSlash(existing.Source))
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenameWarnsAboutShadowedDefinitions(t *testing.T) {
+	team := filepath.Join(t.TempDir(), "team.yaml")
+
+	if err := os.WriteFile(team, []byte("slots:\n  - name: hello\n    cmd: echo team\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "include:\n  - " + filepath.ToSlash(team) + "\nslots:\n  - name: hello\n    cmd: echo mine\n"
+
+	_, stderr, err := runSlotOutput(t, content, "rename", "hello", "greet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(stderr, filepath.ToSlash(team)) || !strings.Contains(stderr, "now refers to") {
+		t.Errorf("got %q, want a warning that %q is visible now", stderr, team)
+	}
+}
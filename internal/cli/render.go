@@ -1,21 +1,40 @@
 package cli
 
 import (
+	"cmp"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"maps"
 	"path/filepath"
-	"strings"
+	"regexp"
+	"slices"
+	"strconv"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/spf13/cobra"
 
-	"github.com/idelchi/slot/internal/render"
-	"github.com/idelchi/slot/internal/store"
+	"github.com/idelchi/slot/internal/prompt"
+	"github.com/idelchi/slot/internal/shell"
+	"github.com/idelchi/slot/internal/slot"
 )
 
+// builtins are the variables provided to every template, besides the positional ARG1..ARGn.
+var builtins = []string{"SLOTS_FILE", "SLOTS_DIR", "CLI_ARGS", "CLI_ARGS_SPLIT", "CLI_ARGS_JSON"}
+
+// positional matches the names of the positional argument variables.
+var positional = regexp.MustCompile(`^ARG[1-9][0-9]*$`)
+
+// isBuiltin reports whether a variable is provided to every template.
+func isBuiltin(name string) bool {
+	return slices.Contains(builtins, name) || positional.MatchString(name)
+}
+
 // Render returns the cobra command for rendering command slots.
 func Render(config *string) *cobra.Command {
+	var interactive, inline, exec bool
+
 	cmd := &cobra.Command{
 		Use:   "render <slot> [key=value...]",
 		Short: "Render a slot",
@@ -23,102 +42,270 @@ func Render(config *string) *cobra.Command {
 			Render a saved command slot, substituting template variables with provided values.
 
 			Templates use Go template syntax: {{.variable}} is replaced with values from key=value arguments.
+			Values are strings, unless given in one of the forms:
+
+			  key:=json    a JSON value, e.g. debug:=true, replicas:=3 or hosts:='["a","b"]'
+			  key+=value   appended to a list, e.g. host+=a host+=b
+			  key@=path    the content of a file
+			  key=-        the content of stdin
 
 			The rendered command is printed to stdout for shell integration.
+
+			With --interactive, every template variable not given as an argument is prompted for
+			on the terminal, using the slot's default as the pre-filled value. Answers for defaults
+			that are not strings, such as lists, are given as JSON.
+
+			The env and dir of a slot are rendered as well, but only applied with --inline, which
+			wraps the command in a subshell: (cd 'dir' || exit 1; export KEY='value'; { command
+			}).
+			The shell integration's 'slot run' uses --inline.
+
+			The commands of dynamic defaults, and of choices checking given values, only run with --exec,
+			so that rendering a slot to look at it has no side effects. Without it, rendering fails
+			when such a command is needed. The shell integration's 'slot run' uses --exec.
 		`),
 		Example: heredoc.Doc(`
 			# Render a command with variable substitution
 			slot render deploy file=k8s.yml ns=production
 
+			# Render with typed values, for templates like {{if .debug}} or {{range .hosts}}
+			slot render deploy debug:=true host+=a host+=b
+
+			# Read a value from stdin
+			git diff | slot render review diff=-
+
 			# Render command without variables
 			slot render hello
+
+			# Prompt for all variables not given on the command line
+			slot render deploy --interactive
+
+			# Include the slot's directory and environment variables
+			slot render deploy --inline
+
+			# Run the commands of dynamic defaults, such as the current branch
+			slot render push --exec
 		`),
-		Args: cobra.MinimumNArgs(1),
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeSlot(config),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			args, afterDash := splitAtDash(cmd, args)
-			if len(args) < 1 {
-				return errors.New("requires at least 1 arg(s), only received 0")
-			}
-
-			store, err := store.New(*config)
+			command, err := prepare(cmd, *config, args, interactive, exec)
 			if err != nil {
 				return err
 			}
 
-			slots, err := store.Load()
-			if err != nil {
-				return err
+			rendered := command.Cmd
+			if inline {
+				rendered = command.Inline()
 			}
 
-			if len(slots) == 0 {
-				return errors.New("no slots to render")
+			if _, err := fmt.Fprintln(cmd.OutOrStdout(), rendered); err != nil {
+				return err
 			}
 
-			slot := args[0]
-			if !slots.Exists(slot) {
-				return fmt.Errorf("no such slot %q: did you mean %q?", slot, slots.Closest(slot))
-			}
+			return nil
+		},
+	}
 
-			selected := slots.Get(slot)
-			variables := map[string]any{}
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "prompt for template variables not given as arguments")
+	cmd.Flags().BoolVar(&inline, "inline", false, "apply the slot's dir and env in a subshell around the command")
+	cmd.Flags().BoolVar(&exec, "exec", false, "run the commands of dynamic defaults and choices")
 
-			maps.Copy(variables, selected.Vars)
+	return cmd
+}
 
-			variables["SLOTS_FILE"] = filepath.ToSlash(store.Path())
-			variables["SLOTS_DIR"] = filepath.ToSlash(filepath.Dir(store.Path()))
-			variables["CLI_ARGS"] = strings.Join(afterDash, " ")
+// prepare renders the slot named by the first of args with the variables given by the rest of args,
+// the arguments after "--", the slot's defaults and, with interactive, the answers to prompts.
+// The commands of dynamic defaults and choices run only with exec and without --no-exec.
+func prepare(cmd *cobra.Command, config string, args []string, interactive, exec bool) (slot.Command, error) {
+	args, afterDash := splitAtDash(cmd, args)
+	if len(args) < 1 {
+		return slot.Command{}, errors.New("requires at least 1 arg(s), only received 0")
+	}
 
-			withs, err := parseWiths(args[1:])
-			if err != nil {
-				return err
-			}
+	store, err := newStore(cmd, config)
+	if err != nil {
+		return slot.Command{}, err
+	}
 
-			maps.Copy(variables, withs)
+	slots, err := loadSlots(cmd, store)
+	if err != nil {
+		return slot.Command{}, err
+	}
 
-			//nolint:errcheck,forcetypeassert  // args are always strings
-			variables["CLI_ARGS_SPLIT"] = strings.Split(variables["CLI_ARGS"].(string), " ")
+	if len(slots) == 0 {
+		return slot.Command{}, errors.New("no slots to render")
+	}
 
-			rendered, err := render.Apply(selected.Cmd, variables)
-			if err != nil {
-				return err
-			}
+	name := args[0]
+	if !slots.Exists(name) {
+		return slot.Command{}, fmt.Errorf("no such slot %q: did you mean %q?", name, slots.Closest(name))
+	}
 
-			if _, err := fmt.Fprintln(cmd.OutOrStdout(), rendered); err != nil {
-				return err
-			}
+	withs, err := parseWiths(args[1:], cmd.InOrStdin())
+	if err != nil {
+		return slot.Command{}, err
+	}
 
-			return nil
-		},
+	selected := slots.Get(name)
+	variables := map[string]any{}
+
+	// Defaults given on the command line are left out, so that their commands do not run.
+	// Defaults named like builtins are ignored, so that ARGn is only set by its argument.
+	for key, value := range selected.Vars {
+		if _, ok := withs[key]; !ok && !isBuiltin(key) {
+			variables[key] = value
+		}
 	}
 
-	return cmd
+	variables["SLOTS_FILE"] = filepath.ToSlash(store.Path())
+	variables["SLOTS_DIR"] = filepath.ToSlash(filepath.Dir(cmp.Or(store.Project(), store.Path())))
+
+	if err := addArgs(variables, afterDash); err != nil {
+		return slot.Command{}, err
+	}
+
+	noExec, _ := cmd.Flags().GetBool("no-exec")
+	runner := selected.Runner(cmd.Context(), noExec || !exec)
+
+	if interactive {
+		// Prompts read from the terminal, so stdin can still provide values with key=-.
+		terminal, err := prompt.Terminal()
+		if err != nil {
+			return slot.Command{}, err
+		}
+
+		defer terminal.Close()
+
+		if err := promptVariables(cmd, selected, variables, withs, terminal); err != nil {
+			return slot.Command{}, err
+		}
+	}
+
+	maps.Copy(variables, withs)
+
+	if err := selected.Params.Apply(variables, runner); err != nil {
+		return slot.Command{}, withExecHint(fmt.Errorf("invalid parameters for slot %q:\n%w", name, err), exec || noExec)
+	}
+
+	command, err := selected.Command(variables, runner)
+	if err != nil {
+		return slot.Command{}, withExecHint(fmt.Errorf("slot %q: %w", name, err), exec || noExec)
+	}
+
+	return command, nil
+}
+
+// withExecHint adds a hint to pass --exec to err if it was caused by refusing to run a command,
+// unless running commands was asked for or turned off with --no-exec.
+func withExecHint(err error, decided bool) error {
+	if decided || !errors.Is(err, slot.ErrRefused) {
+		return err
+	}
+
+	return fmt.Errorf("%w\nrender runs the commands of dynamic defaults and choices only with --exec", err)
 }
 
-// parseWiths parses key=value pairs into a key-value map.
-func parseWiths(keyValues []string) (map[string]any, error) {
-	var errs []error
+// addArgs adds the variables for the arguments after "--", keeping their boundaries:
+// CLI_ARGS joins them shell-quoted, CLI_ARGS_SPLIT and CLI_ARGS_JSON hold them as a list, and ARG1..ARGn one each.
+func addArgs(variables map[string]any, args []string) error {
+	args = append([]string{}, args...)
+
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	variables["CLI_ARGS"] = shell.Join(args)
+	variables["CLI_ARGS_SPLIT"] = args
+	variables["CLI_ARGS_JSON"] = string(encoded)
+
+	for i, arg := range args {
+		variables["ARG"+strconv.Itoa(i+1)] = arg
+	}
 
-	out := make(map[string]any)
+	return nil
+}
 
-	for _, keyValue := range keyValues {
-		key, value, found := strings.Cut(keyValue, "=")
+// promptVariables asks for every variable used by the slot's templates that is neither built in nor
+// given in withs, reading the answers from answers and storing them in withs.
+// Prompts are written to stderr to keep stdout clean. An empty answer keeps the default with its type;
+// answers for typed defaults, such as lists or numbers, are decoded as JSON like key:=json arguments.
+func promptVariables(cmd *cobra.Command, selected *slot.Slot, defaults, withs map[string]any, answers io.Reader) error {
+	names, err := selected.Referenced()
+	if err != nil {
+		return err
+	}
 
-		if !found {
-			errs = append(errs, fmt.Errorf("missing value: %q", keyValue))
+	prompter := prompt.New(answers, cmd.ErrOrStderr())
 
+	for _, name := range names {
+		if _, ok := withs[name]; ok || isBuiltin(name) {
 			continue
 		}
 
-		if key == "" {
-			errs = append(errs, fmt.Errorf("missing key: %q", keyValue))
+		label := name
+		def, hasDefault := defaults[name]
 
-			continue
+		if param := selected.Params.Get(name); param != nil {
+			if param.Description != "" {
+				label = fmt.Sprintf("%s (%s)", name, param.Description)
+			}
+
+			if !hasDefault && param.Default != nil {
+				def, hasDefault = param.Default, true
+			}
 		}
 
-		out[key] = value
+		text := ""
+		if hasDefault {
+			text = formatDefault(def)
+		}
+
+		answer, err := prompter.Ask(label, text)
+		if err != nil {
+			return err
+		}
+
+		_, isString := def.(string)
+		_, isDynamic, _ := slot.ParseDynamic(def)
+
+		switch {
+		case hasDefault && answer == text:
+			withs[name] = def
+		case answer == "":
+		case hasDefault && !isString && !isDynamic:
+			parsed, err := decodeJSON(answer)
+			if err != nil {
+				return fmt.Errorf("%q: %w", name, err)
+			}
+
+			withs[name] = parsed
+		default:
+			withs[name] = answer
+		}
+	}
+
+	return nil
+}
+
+// formatDefault formats a default for a prompt: strings as they are, dynamic defaults as $(command),
+// which runs only if the default is kept, and other values as JSON.
+func formatDefault(value any) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+
+	if dynamic, ok, err := slot.ParseDynamic(value); ok && err == nil {
+		return dynamic.String()
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
 	}
 
-	return out, errors.Join(errs...)
+	return string(encoded)
 }
 
 // splitAtDash splits args at the first occurrence of "--".
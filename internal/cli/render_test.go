@@ -0,0 +1,353 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/slot/internal/render"
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// runSlot runs slot with args against a slots file with the given content, in a temporary home directory,
+// and returns its standard output.
+func runSlot(t *testing.T, content string, args ...string) (string, error) {
+	t.Helper()
+
+	stdout, _, err := runSlotOutput(t, content, args...)
+
+	return stdout, err
+}
+
+// runSlotOutput runs slot like runSlot and returns its standard output and standard error.
+func runSlotOutput(t *testing.T, content string, args ...string) (string, string, error) {
+	t.Helper()
+
+	return execSlot(newHome(t, content), append([]string{"--no-project"}, args...)...)
+}
+
+// newHome sets up a temporary home directory with a slots file with the given content and returns its path.
+func newHome(t *testing.T, content string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	t.Setenv("HOME", dir)
+	t.Setenv("USERPROFILE", dir)
+	t.Setenv("SLOTS_FILE", "")
+
+	config := filepath.Join(dir, "slots.yaml")
+
+	if err := os.WriteFile(config, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return config
+}
+
+// execSlot runs slot with args against the slots file config and returns its standard output and standard error.
+// Its standard output is not a terminal, like the output captured by the shell integration.
+// Project slots files are found from the working directory unless args contain --no-project.
+func execSlot(config string, args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+
+	root := newRoot("test")
+	root.SetArgs(append([]string{"--config", config}, args...))
+	root.SetOut(&stdout)
+	root.SetErr(&stderr)
+	root.SetIn(strings.NewReader(""))
+
+	err := root.Execute()
+
+	return strings.TrimSuffix(stdout.String(), "\n"), stderr.String(), err
+}
+
+func TestAddArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args     []string
+		cliArgs  string
+		json     string
+		expected []string
+	}{
+		{nil, ``, `[]`, []string{}},
+		{[]string{""}, `''`, `[""]`, []string{""}},
+		{[]string{"-i", "foo bar"}, `-i 'foo bar'`, `["-i","foo bar"]`, []string{"-i", "foo bar"}},
+		{[]string{`it's`, `"quoted"`}, `'it'\''s' '"quoted"'`, `["it's","\"quoted\""]`, []string{`it's`, `"quoted"`}},
+		{[]string{"$(echo x)", "a\nb"}, `'$(echo x)' 'a` + "\n" + `b'`, `["$(echo x)","a\nb"]`, []string{"$(echo x)", "a\nb"}},
+	}
+
+	for _, test := range tests {
+		variables := map[string]any{}
+
+		if err := addArgs(variables, test.args); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := variables["CLI_ARGS"]; got != test.cliArgs {
+			t.Errorf("%q: CLI_ARGS = %v, want %v", test.args, got, test.cliArgs)
+		}
+
+		if got := variables["CLI_ARGS_JSON"]; got != test.json {
+			t.Errorf("%q: CLI_ARGS_JSON = %v, want %v", test.args, got, test.json)
+		}
+
+		split, ok := variables["CLI_ARGS_SPLIT"].([]string)
+		if !ok || !slices.Equal(split, test.expected) || split == nil {
+			t.Errorf("%q: CLI_ARGS_SPLIT = %#v, want %#v", test.args, variables["CLI_ARGS_SPLIT"], test.expected)
+		}
+
+		for i, arg := range test.args {
+			if got := variables["ARG"+strconv.Itoa(i+1)]; got != arg {
+				t.Errorf("%q: ARG%d = %v, want %v", test.args, i+1, got, arg)
+			}
+		}
+
+		if _, ok := variables["ARG"+strconv.Itoa(len(test.args)+1)]; ok {
+			t.Errorf("%q: ARG%d is set", test.args, len(test.args)+1)
+		}
+	}
+}
+
+func TestArgOutOfRange(t *testing.T) {
+	t.Parallel()
+
+	variables := map[string]any{}
+
+	if err := addArgs(variables, []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := render.Apply("{{.ARG3}}", variables); err == nil || !strings.Contains(err.Error(), "ARG3") {
+		t.Errorf("rendering ARG3 with two arguments: got error %v, want a missing ARG3", err)
+	}
+
+	got, err := render.Apply(`{{.ARG2}}{{range .CLI_ARGS_SPLIT}}[{{.}}]{{end}}`, variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "b[a][b]" {
+		t.Errorf("got %q, want %q", got, "b[a][b]")
+	}
+}
+
+func TestIsBuiltin(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"SLOTS_FILE":     true,
+		"SLOTS_DIR":      true,
+		"CLI_ARGS":       true,
+		"CLI_ARGS_SPLIT": true,
+		"CLI_ARGS_JSON":  true,
+		"ARG1":           true,
+		"ARG10":          true,
+		"ARG0":           false,
+		"ARG01":          false,
+		"ARG":            false,
+		"ARGS":           false,
+		"ARG1X":          false,
+		"arg1":           false,
+		"CLI_ARGS_X":     false,
+		"cli_args":       false,
+	}
+
+	for name, want := range tests {
+		if got := isBuiltin(name); got != want {
+			t.Errorf("isBuiltin(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestRenderBuiltinsOverrideDefaults(t *testing.T) {
+	const slots = `slots:
+  - name: args
+    cmd: '{{.ARG1}}|{{.CLI_ARGS}}|{{.ARG0}}|{{len .CLI_ARGS_SPLIT}}'
+    vars:
+      ARG1: default
+      CLI_ARGS: default
+      CLI_ARGS_SPLIT: [x, y, z]
+      ARG0: mine
+`
+
+	got, err := runSlot(t, slots, "render", "args", "--", "foo bar", "it's")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `foo bar|'foo bar' 'it'\''s'|mine|2`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	if _, err := runSlot(t, slots, "render", "args"); err == nil {
+		t.Error("rendering ARG1 without arguments succeeded, although the builtin replaces its default")
+	}
+}
+
+func TestPromptVariables(t *testing.T) {
+	t.Parallel()
+
+	selected := &slot.Slot{Name: "deploy", Cmd: "{{.hosts}} {{.replicas}} {{.env}} {{.tag}} {{.given}}"}
+	defaults := map[string]any{"hosts": []any{"a", "b"}, "replicas": 2, "env": "dev", "tag": "latest"}
+	withs := map[string]any{"given": "x"}
+
+	var prompts bytes.Buffer
+
+	cmd := &cobra.Command{}
+	cmd.SetErr(&prompts)
+
+	// Keep the list, change the number, keep the string and change the string.
+	answers := strings.NewReader("\n3\n\nv1\n")
+
+	if err := promptVariables(cmd, selected, defaults, withs, answers); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		"hosts":    []any{"a", "b"},
+		"replicas": 3,
+		"env":      "dev",
+		"tag":      "v1",
+		"given":    "x",
+	}
+
+	if !reflect.DeepEqual(withs, want) {
+		t.Errorf("got %#v, want %#v", withs, want)
+	}
+
+	if !strings.Contains(prompts.String(), `hosts [["a","b"]]`) {
+		t.Errorf("list default not shown as JSON: %s", prompts.String())
+	}
+
+	err := promptVariables(cmd, selected, defaults, map[string]any{}, strings.NewReader("[a\n"))
+	if err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Errorf("got error %v for an invalid list, want invalid JSON", err)
+	}
+}
+
+func TestRenderDynamicDefaultsLazily(t *testing.T) {
+	dir := t.TempDir()
+	taken, untaken := filepath.Join(dir, "taken"), filepath.Join(dir, "untaken")
+
+	slots := `slots:
+  - name: lazy
+    cmd: '{{if .debug}}{{.untaken}}{{else}}{{.taken}}{{end}}'
+    shell: sh
+    vars:
+      debug: false
+      taken:
+        sh: touch ` + taken + ` && echo taken
+      untaken:
+        sh: touch ` + untaken + ` && echo untaken
+`
+
+	if _, err := runSlot(t, slots, "render", "lazy"); err == nil || !strings.Contains(err.Error(), "--exec") {
+		t.Errorf("rendering without --exec: got error %v, want a refusal", err)
+	}
+
+	if _, err := os.Stat(taken); err == nil {
+		t.Error("render ran a command without --exec")
+	}
+
+	got, err := runSlot(t, slots, "render", "lazy", "--exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "taken" {
+		t.Errorf("got %q, want %q", got, "taken")
+	}
+
+	if _, err := os.Stat(untaken); err == nil {
+		t.Error("the default in the untaken branch ran")
+	}
+}
+
+func TestRenderChecksDynamicDefaults(t *testing.T) {
+	const slots = `slots:
+  - name: checked
+    cmd: 'echo {{.count}}'
+    shell: sh
+    vars:
+      count:
+        sh: echo many
+    params:
+      - name: count
+        type: int
+`
+
+	if _, err := runSlot(t, slots, "render", "checked", "--exec"); err == nil ||
+		!strings.Contains(err.Error(), "not an integer") {
+		t.Errorf("got error %v, want the output of the default checked against its parameter", err)
+	}
+
+	got, err := runSlot(t, slots, "render", "checked", "count=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "echo 3" {
+		t.Errorf("got %q, want %q", got, "echo 3")
+	}
+}
+
+func TestRenderVarsOverrideParamDefaults(t *testing.T) {
+	content := "slots:\n  - name: greet\n    cmd: echo {{.name}}\n    vars:\n      name: vars\n" +
+		"    params:\n      - name: name\n        default: param\n"
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"render", "greet"}, "echo vars"},
+		{[]string{"render", "greet", "name=arg"}, "echo arg"},
+	}
+
+	for _, test := range tests {
+		got, err := runSlot(t, content, test.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != test.want {
+			t.Errorf("%v: got %q, want %q", test.args, got, test.want)
+		}
+	}
+}
+
+func TestRenderHintsAtExec(t *testing.T) {
+	const slots = `slots:
+  - name: dynamic
+    cmd: 'echo {{.user}}'
+    vars:
+      user:
+        sh: echo me
+  - name: choices
+    cmd: 'echo {{.env}}'
+    params:
+      - name: env
+        choices:
+          sh: printf 'dev\nprod\n'
+`
+
+	tests := [][]string{
+		{"render", "dynamic"},
+		{"render", "choices", "env=dev"},
+	}
+
+	for _, args := range tests {
+		_, err := runSlot(t, slots, args...)
+		if err == nil || !strings.Contains(err.Error(), "only with --exec") {
+			t.Errorf("%q: got error %v, want a hint to pass --exec", args, err)
+		}
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-
-	"github.com/idelchi/slot/internal/store"
 )
 
 // Remove returns the cobra command for removing command slots.
@@ -17,12 +15,12 @@ func Remove(config *string) *cobra.Command {
 		Aliases: []string{"rm"},
 		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			store, err := store.New(*config)
+			store, err := newStore(cmd, *config)
 			if err != nil {
 				return err
 			}
 
-			allSlots, err := store.Load()
+			allSlots, err := loadSlots(cmd, store)
 			if err != nil {
 				return err
 			}
@@ -1,16 +1,27 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/spf13/cobra"
 
+	"github.com/idelchi/slot/internal/slot"
 	"github.com/idelchi/slot/internal/store"
 )
 
 // Execute runs the root command for the slot CLI application.
 func Execute(version string) error {
+	return newRoot(version).Execute()
+}
+
+// newRoot returns the root command with all subcommands.
+func newRoot(version string) *cobra.Command {
 	root := &cobra.Command{
 		Use:   "slot",
 		Short: "Manage named shell command slots",
@@ -47,6 +58,7 @@ func Execute(version string) error {
 		SilenceUsage:  true,
 	}
 
+	root.SetContext(context.WithValue(context.Background(), warningsKey{}, map[string]bool{}))
 	root.SetVersionTemplate("{{ .Version }}\n")
 	root.SetHelpCommand(&cobra.Command{Hidden: true})
 
@@ -62,15 +74,169 @@ func Execute(version string) error {
 	}
 
 	root.PersistentFlags().StringVar(&config, "config", config, "path to the configuration file")
+	root.PersistentFlags().Bool("strict", false, "fail instead of warning on shadowed slots and changed trusted files")
+	root.PersistentFlags().Bool("no-exec", false, "refuse to run the commands of dynamic variable defaults")
+	root.PersistentFlags().Bool("no-project", false, "ignore project slots files in the current directory and its parents")
 
 	root.AddCommand(
 		Save(&config),
 		Render(&config),
+		Exec(&config),
+		Vars(&config),
+		Show(&config),
 		List(&config),
+		Edit(&config),
+		Rename(&config),
+		Copy(&config),
+		Move(&config),
 		Remove(&config),
+		Update(&config),
+		Trust(&config),
 		Path(&config),
 		Init(),
 	)
 
-	return root.Execute()
+	return root
+}
+
+// newStore opens the slots file, layered below the project slots file found from the working directory
+// unless --no-project is set. Project files come with the repositories they are in, so they are
+// ignored with a warning until their current content is trusted with 'slot trust'.
+func newStore(cmd *cobra.Command, config string) (store.Store, error) {
+	return openStore(cmd, config, false)
+}
+
+// openStore opens the slots file like newStore. With untrusted, the project slots file is layered
+// even if it is not trusted, for commands that only review it.
+func openStore(cmd *cobra.Command, config string, untrusted bool) (store.Store, error) {
+	slots, err := store.New(config)
+	if err != nil {
+		return slots, err
+	}
+
+	project, ok := findProject(cmd)
+	if !ok {
+		return slots, nil
+	}
+
+	if !untrusted {
+		trusted, err := store.Trusted(project)
+		if err != nil {
+			return slots, err
+		}
+
+		if !trusted {
+			warn(cmd, fmt.Errorf(
+				"ignoring project slots file %q, which is not trusted, review it and run 'slot trust %s'",
+				filepath.ToSlash(project),
+				filepath.ToSlash(project),
+			))
+
+			return slots, nil
+		}
+	}
+
+	return slots.WithProject(project), nil
+}
+
+// findProject returns the project slots file found from the working directory, unless --no-project is set.
+func findProject(cmd *cobra.Command) (string, bool) {
+	if ignore, _ := cmd.Flags().GetBool("no-project"); ignore {
+		return "", false
+	}
+
+	return store.FindProject(".")
+}
+
+// loadSlots returns the visible slots of the store, see loadAll.
+func loadSlots(cmd *cobra.Command, store store.Store) (slot.Slots, error) {
+	slots, err := loadAll(cmd, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return slots.Unique(), nil
+}
+
+// loadAll returns all slots of the store, including shadowed ones.
+// Each shadowed slot and each file changed since it was trusted or never trusted is reported as a warning,
+// see warn, or as an error with --strict.
+func loadAll(cmd *cobra.Command, store store.Store) (slot.Slots, error) {
+	slots, err := store.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := store.Verify()
+	if err != nil {
+		return nil, err
+	}
+
+	strict, _ := cmd.Flags().GetBool("strict")
+
+	var errs []error
+
+	for _, change := range changes {
+		err := fmt.Errorf(
+			"%q changed since it was trusted, review the changes and run 'slot trust':\n%s",
+			filepath.ToSlash(change.Path),
+			strings.TrimSuffix(change.Diff, "\n"),
+		)
+
+		if change.New {
+			err = fmt.Errorf("%q is not trusted, review it and run 'slot trust'", filepath.ToSlash(change.Path))
+		}
+
+		if !strict {
+			warn(cmd, err)
+
+			continue
+		}
+
+		errs = append(errs, err)
+	}
+
+	for _, shadow := range slots.Shadows() {
+		err := fmt.Errorf(
+			"slot %q in %q is shadowed by its definition in %q",
+			shadow.Slot.Name,
+			filepath.ToSlash(shadow.Slot.Source),
+			filepath.ToSlash(shadow.By.Source),
+		)
+
+		if !strict {
+			warn(cmd, err)
+
+			continue
+		}
+
+		errs = append(errs, err)
+	}
+
+	return slots, errors.Join(errs...)
+}
+
+// warningsKey is the context key of the warnings printed by a command.
+type warningsKey struct{}
+
+// warn prints a warning to stderr, once per command. Warnings are left out during shell completion.
+// Output captured by the shell integration still shows them, as it only captures stdout.
+// Use --strict to fail on them instead.
+func warn(cmd *cobra.Command, err error) {
+	// Completion calls the completion functions of commands that are not executed themselves.
+	if cmd.CalledAs() == "" {
+		return
+	}
+
+	if ctx := cmd.Context(); ctx != nil {
+		if printed, ok := ctx.Value(warningsKey{}).(map[string]bool); ok {
+			if printed[err.Error()] {
+				return
+			}
+
+			printed[err.Error()] = true
+		}
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "warning: %v\n", err)
 }
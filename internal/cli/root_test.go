@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		args     []string
+		warnings int
+	}{
+		{[]string{"list"}, 1},
+		{[]string{"render", "hello"}, 1},
+		{[]string{"__complete", "render", ""}, 0},
+		{[]string{"__complete", "render", "hello", ""}, 0},
+	}
+
+	for _, test := range tests {
+		team := filepath.Join(t.TempDir(), "team.yaml")
+
+		if err := os.WriteFile(team, []byte("slots:\n  - name: hello\n    cmd: echo team\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		// The slot in the slots file shadows the one in the included file.
+		content := "include:\n  - " + filepath.ToSlash(team) + "\nslots:\n  - name: hello\n    cmd: echo mine\n"
+
+		_, stderr, err := runSlotOutput(t, content, test.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := strings.Count(stderr, "is shadowed by"); got != test.warnings {
+			t.Errorf("%q: got %d warnings, want %d:\n%s", test.args, got, test.warnings, stderr)
+		}
+	}
+}
+
+func TestWarningsWithCapturedOutput(t *testing.T) {
+	team := filepath.Join(t.TempDir(), "team.yaml")
+
+	if err := os.WriteFile(team, []byte("slots:\n  - name: team\n    cmd: echo team\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := newHome(t, "include:\n  - "+filepath.ToSlash(team)+"\n")
+
+	if _, _, err := execSlot(config, "--no-project", "trust"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(team, []byte("slots:\n  - name: team\n    cmd: echo changed\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := execSlot(config, "--no-project", "render", "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stdout != "echo changed" {
+		t.Errorf("got %q, want %q", stdout, "echo changed")
+	}
+
+	if !strings.Contains(stderr, "changed since it was trusted") || !strings.Contains(stderr, "+    cmd: echo changed") {
+		t.Errorf("got %q, want the changes to %q on stderr", stderr, team)
+	}
+}
@@ -2,12 +2,12 @@ package cli
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/spf13/cobra"
 
 	"github.com/idelchi/slot/internal/slot"
-	"github.com/idelchi/slot/internal/store"
 )
 
 // Save returns the cobra command for saving command slots.
@@ -17,6 +17,8 @@ func Save(config *string) *cobra.Command {
 		description string
 		force       bool
 		vars        []string
+		file        string
+		local       bool
 	)
 
 	cmd := &cobra.Command{
@@ -27,6 +29,12 @@ func Save(config *string) *cobra.Command {
 
 			Commands can include Go template variables like {{.file}} or {{.env}} that will be
 			replaced with values when rendering.
+
+			Slots are saved to the slots file, or with --file to any file it includes.
+			With --local, they are saved to the project slots file (.slots.yaml) found in the
+			current directory or its parents, which is created in the current directory if needed.
+			Files that are not included yet are created and added to the includes of the slots file.
+			Slots saved to a named include are prefixed with its name, e.g. 'team:deploy'.
 		`),
 		//nolint:dupword	// False warning
 		Example: heredoc.Doc(`
@@ -39,53 +47,79 @@ func Save(config *string) *cobra.Command {
 			# Save with default variables
 			slot save deploy 'kubectl apply -f {{.file}} -n {{.namespace}}' --var file=k8s.yml --var namespace=default
 
+			# Save typed defaults, written to the slots file as a number and a list
+			slot save scale 'kubectl scale --replicas {{.replicas}}{{range .names}} {{.}}{{end}}' --var replicas:=3 --var names:='["web","api"]'
+
 			# Overwrite existing slot
 			slot save deploy 'kubectl apply -f {{.file}} --namespace {{.ns}}' --force
 
 			# Save a slot that outputs the content of the slots file
 			slot save slots 'cat $(slot ls | tail -1)'
+
+			# Save into the project slots file
+			slot save build 'go build ./...' --local
+
+			# Save into an included team file
+			slot save deploy 'kubectl apply -f {{.file}}' --file ./team/slots.yaml
 		`),
 		Args: cobra.ExactArgs(2), //nolint:mnd   // Clear from the context
 		RunE: func(cmd *cobra.Command, args []string) error {
-			store, err := store.New(*config)
+			store, err := newStore(cmd, *config)
 			if err != nil {
 				return err
 			}
 
-			allSlots, err := store.Load()
+			allSlots, err := loadSlots(cmd, store)
 			if err != nil {
 				return err
 			}
 
-			slots, err := store.LoadLocal()
+			if local {
+				if project, ok := findProject(cmd); ok && store.Project() == "" {
+					return fmt.Errorf(
+						"project slots file %q is not trusted, review it and run 'slot trust %s'",
+						filepath.ToSlash(project),
+						filepath.ToSlash(project),
+					)
+				}
+
+				store = store.WithLocalProject()
+				file = store.Project()
+			}
+
+			name, err := store.Qualify(file, args[0])
 			if err != nil {
 				return err
 			}
 
-			name, rawCommand := args[0], args[1]
+			rawCommand := args[1]
 			if allSlots.Exists(name) && !force {
 				return fmt.Errorf("slot %q exists (use --force)", name)
 			}
 
-			slotVars, err := parseWiths(vars)
+			slotVars, err := parseWiths(vars, cmd.InOrStdin())
 			if err != nil {
 				return err
 			}
 
-			slots.Delete(name)
-
-			slots.Add(slot.Slot{
+			saved := slot.Slot{
 				Name:        name,
 				Description: description,
 				Cmd:         rawCommand,
 				Vars:        slotVars,
 				Tags:        tags,
-			})
+			}
 
-			if err := store.Save(slots); err != nil {
+			// Save checks the target file again under its lock, as another process may have added the slot.
+			included, err := store.Save(file, saved, force)
+			if err != nil {
 				return err
 			}
 
+			if included {
+				fmt.Fprintf(cmd.ErrOrStderr(), "created %q and added it to the includes of %q\n", file, store.Path())
+			}
+
 			fmt.Fprintf(cmd.OutOrStdout(), "saved %q\n", name)
 
 			return nil
@@ -95,7 +129,11 @@ func Save(config *string) *cobra.Command {
 	cmd.Flags().StringSliceVar(&tags, "tags", nil, "tags for the slot (repeatable)")
 	cmd.Flags().StringVar(&description, "description", "", "description for the slot")
 	cmd.Flags().BoolVar(&force, "force", false, "overwrite existing slot")
-	cmd.Flags().StringArrayVar(&vars, "var", nil, "default template variable (key=value, repeatable)")
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "default template variable (key=value, key:=json, key+=value, key@=path or key=-, repeatable)")
+	cmd.Flags().StringVar(&file, "file", "", "file to save to: an include as listed, or a path (default: the slots file)")
+	cmd.Flags().BoolVar(&local, "local", false, "save to the project slots file")
+
+	cmd.MarkFlagsMutuallyExclusive("file", "local")
 
 	return cmd
 }
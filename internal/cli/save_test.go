@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectSlots(t *testing.T) {
+	config := newHome(t, "slots:\n  - name: hello\n    cmd: echo user\n")
+
+	repo := t.TempDir()
+	project := filepath.Join(repo, ".slots.yaml")
+	sub := filepath.Join(repo, "a", "b")
+
+	if err := os.MkdirAll(sub, 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(project, []byte("slots:\n  - name: hello\n    cmd: echo project\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The project file is found from a subdirectory of the repository.
+	t.Chdir(sub)
+
+	stdout, stderr, err := execSlot(config, "render", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stdout != "echo user" || !strings.Contains(stderr, "not trusted") {
+		t.Errorf("got %q and %q, want the user slot and a warning about the untrusted project file", stdout, stderr)
+	}
+
+	if _, _, err := execSlot(config, "trust", project); err != nil {
+		t.Fatal(err)
+	}
+
+	if stdout, _, err := execSlot(config, "render", "hello"); err != nil || stdout != "echo project" {
+		t.Errorf("got %q, %v, want the project slot to take precedence", stdout, err)
+	}
+
+	if _, _, err := execSlot(config, "save", "build", "go build", "--local"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), "name: build") {
+		t.Errorf("slot saved with --local is not in the project file:\n%s", data)
+	}
+
+	if _, err := os.Stat(filepath.Join(sub, ".slots.yaml")); err == nil {
+		t.Error("save --local created a project file in the working directory instead of using the existing one")
+	}
+
+	if stdout, _, err := execSlot(config, "render", "build"); err != nil || stdout != "go build" {
+		t.Errorf("got %q, %v, want the saved project slot", stdout, err)
+	}
+}
+
+func TestSaveLocalCreatesProjectFile(t *testing.T) {
+	config := newHome(t, "")
+	dir := t.TempDir()
+
+	t.Chdir(dir)
+
+	if _, _, err := execSlot(config, "save", "build", "go build", "--local"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".slots.yaml")); err != nil {
+		t.Errorf("save --local did not create the project file: %v", err)
+	}
+
+	stdout, stderr, err := execSlot(config, "render", "build")
+	if err != nil || stdout != "go build" || stderr != "" {
+		t.Errorf("got %q, %q, %v, want the slot of the created project file without warnings", stdout, stderr, err)
+	}
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// Show returns the cobra command for displaying a single slot in full.
+func Show(config *string) *cobra.Command {
+	var output, format string
+
+	cmd := &cobra.Command{
+		Use:   "show <slot>",
+		Short: "Show a slot in full",
+		Long: heredoc.Doc(`
+			Show a saved slot in full, including the file that defines it, the raw
+			template, default variables, parameters, tags and description.
+		`),
+		Example: heredoc.Doc(`
+			# Show the 'deploy' slot
+			slot show deploy
+
+			# Show the 'deploy' slot as JSON
+			slot show deploy --output json
+
+			# Print the raw command template of the 'deploy' slot
+			slot show deploy --format '{{.Cmd}}'
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := newStore(cmd, *config)
+			if err != nil {
+				return err
+			}
+
+			slots, err := loadSlots(cmd, store)
+			if err != nil {
+				return err
+			}
+
+			if len(slots) == 0 {
+				return errors.New("no slots to show")
+			}
+
+			name := args[0]
+			if !slots.Exists(name) {
+				return fmt.Errorf("no such slot %q: did you mean %q?", name, slots.Closest(name))
+			}
+
+			selected := slots.Get(name)
+
+			if format != "" {
+				return slot.Slots{*selected}.RenderTemplate(format, cmd.OutOrStdout())
+			}
+
+			return selected.Render(output, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "output format (text, yaml, json)")
+	cmd.Flags().StringVar(&format, "format", "", "Go template applied to the slot, as for 'slot list --format'")
+
+	cmd.MarkFlagsMutuallyExclusive("format", "output")
+
+	return cmd
+}
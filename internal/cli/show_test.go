@@ -0,0 +1,17 @@
+package cli
+
+import "testing"
+
+func TestShowFormat(t *testing.T) {
+	// The command holds a real newline, an escaped one and caret notation, which must all come back as they are.
+	content := "slots:\n  - name: raw\n    cmd: \"printf 'a\\\\n^J\\\\t'\\nwc -l\"\n  - name: other\n    cmd: true\n"
+
+	got, err := runSlot(t, content, "show", "raw", "--format", "{{.Cmd}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "printf 'a\\n^J\\t'\nwc -l"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+)
+
+// Trust returns the cobra command for marking slots files as reviewed.
+func Trust(config *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trust [file...]",
+		Short: "Mark slots files as reviewed",
+		Long: heredoc.Doc(`
+			Record the current content of slots files as reviewed in the local trust database.
+
+			Once a file is trusted, every command warns with a diff when it changes, or fails
+			with --strict, until it is trusted again. Without arguments, the project slots file and
+			every included file are trusted, but not the slots file itself, which is your own and
+			never checked. Files are given as in 'slot save --file'.
+
+			Project slots files (.slots.yaml) are only loaded once their current content is trusted,
+			as they come with the repositories they are in.
+
+			To fail on any change instead, pin an include with its sha256 hash.
+		`),
+		Example: heredoc.Doc(`
+			# Trust all included files after reviewing them
+			slot trust
+
+			# Trust a single included file
+			slot trust ./team/slots.yaml
+
+			# Trust the project slots file of a cloned repository after reviewing it
+			slot trust .slots.yaml
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore(cmd, *config, true)
+			if err != nil {
+				return err
+			}
+
+			trusted, err := store.Trust(args...)
+			if err != nil {
+				return err
+			}
+
+			if len(trusted) == 0 {
+				fmt.Fprintln(cmd.ErrOrStderr(), "all files are already trusted")
+
+				return nil
+			}
+
+			for _, path := range trusted {
+				fmt.Fprintf(cmd.OutOrStdout(), "trusted %q\n", filepath.ToSlash(path))
+			}
+
+			return nil
+		},
+	}
+
+	return cmd
+}
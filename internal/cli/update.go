@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+)
+
+// Update returns the cobra command for refreshing included git repositories.
+func Update(config *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Refresh included git repositories",
+		Long: heredoc.Doc(`
+			Fetch the git repositories included by the slots file and its includes again,
+			and check out their configured refs.
+
+			Repositories are cloned into a cache on first use and are not fetched again
+			while loading slots, so slots keep working offline until the next update.
+		`),
+		Example: heredoc.Doc(`
+			# Pull the latest shared slots
+			slot update
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, err := newStore(cmd, *config)
+			if err != nil {
+				return err
+			}
+
+			updated, err := store.Update()
+			if err != nil {
+				return err
+			}
+
+			if len(updated) == 0 {
+				fmt.Fprintln(cmd.ErrOrStderr(), "no git repositories included")
+
+				return nil
+			}
+
+			for _, repository := range updated {
+				fmt.Fprintf(cmd.OutOrStdout(), "updated %s\n", repository)
+			}
+
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// Vars returns the cobra command for listing the variables used by a slot.
+func Vars(config *string) *cobra.Command {
+	var (
+		output  string
+		choices string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "vars <slot>",
+		Short: "List the variables of a slot",
+		Long: heredoc.Doc(`
+			List the template variables referenced by a slot without rendering it.
+
+			For each variable, the default value, whether it is built in, and whether
+			rendering fails without it are shown.
+
+			With --choices, the valid values of a single variable are printed one per line instead,
+			running the command that generates them if needed. Nothing is printed for variables
+			without choices.
+		`),
+		Example: heredoc.Doc(`
+			# Show the variables used by the 'deploy' slot
+			slot vars deploy
+
+			# Machine-readable output
+			slot vars deploy --output tsv
+			slot vars deploy --output json
+
+			# Valid values of the 'namespace' variable
+			slot vars deploy --choices namespace
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := newStore(cmd, *config)
+			if err != nil {
+				return err
+			}
+
+			slots, err := loadSlots(cmd, store)
+			if err != nil {
+				return err
+			}
+
+			if len(slots) == 0 {
+				return errors.New("no slots to inspect")
+			}
+
+			name := args[0]
+			if !slots.Exists(name) {
+				return fmt.Errorf("no such slot %q: did you mean %q?", name, slots.Closest(name))
+			}
+
+			selected := slots.Get(name)
+
+			if choices != "" {
+				return printChoices(cmd, selected, choices)
+			}
+
+			variables, err := selected.Variables(isBuiltin)
+			if err != nil {
+				return err
+			}
+
+			return variables.Render(output, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format (table, tsv, json, yaml)")
+	cmd.Flags().StringVar(&choices, "choices", "", "print the valid values of the named variable, one per line")
+
+	cmd.MarkFlagsMutuallyExclusive("output", "choices")
+
+	return cmd
+}
+
+// printChoices prints the choices of the named variable of the slot, one per line.
+func printChoices(cmd *cobra.Command, selected *slot.Slot, name string) error {
+	param := selected.Params.Get(name)
+	if param == nil {
+		return nil
+	}
+
+	noExec, _ := cmd.Flags().GetBool("no-exec")
+
+	choices, err := param.Choices.List(selected.Runner(cmd.Context(), noExec))
+	if err != nil {
+		return fmt.Errorf("choices of %q: %w", name, err)
+	}
+
+	for _, choice := range choices {
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), choice); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestVarsOutput(t *testing.T) {
+	content := "slots:\n  - name: greet\n    cmd: echo {{.name}} {{.user}}\n    vars:\n      user: $(whoami)\n"
+
+	tsv, err := runSlot(t, content, "vars", "greet", "--output", "tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(tsv, "user\t$(whoami)") {
+		t.Errorf("got %q, want a tsv row with the dynamic default of user", tsv)
+	}
+
+	out, err := runSlot(t, content, "vars", "greet", "-o", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var variables []map[string]any
+	if err := json.Unmarshal([]byte(out), &variables); err != nil {
+		t.Fatalf("unmarshalling %q: %v", out, err)
+	}
+
+	defaults := map[string]any{}
+	for _, variable := range variables {
+		defaults[variable["name"].(string)] = variable["default"]
+	}
+
+	if defaults["user"] != "$(whoami)" || defaults["name"] != nil {
+		t.Errorf("got defaults %v, want $(whoami) for user and none for name", defaults)
+	}
+
+	if _, err := runSlot(t, content, "vars", "greet", "-o", "tsv", "--choices", "name"); err == nil {
+		t.Error("got no error for --output with --choices")
+	}
+}
+
+func TestVarsEscapesDefaults(t *testing.T) {
+	content := "slots:\n  - name: greet\n    cmd: echo {{.text}}\n    vars:\n      text: \"a\\tb\\nc\"\n"
+
+	tsv, err := runSlot(t, content, "vars", "greet", "--output", "tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Split(tsv, "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "text\ta^Ib^Jc\t") {
+		t.Errorf("got %q, want the default escaped on a single row", tsv)
+	}
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Operators that can precede the "=" of a key=value argument.
+const (
+	// withJSON parses the value as JSON: key:=json.
+	withJSON = ":"
+	// withAppend appends the value to a list: key+=value.
+	withAppend = "+"
+	// withFile reads the value from a file: key@=path.
+	withFile = "@"
+)
+
+// stdinValue as the value of a plain key=value argument reads the value from stdin.
+const stdinValue = "-"
+
+// splitOperator splits the operator preceding the "=" of a key=value argument, if any, from the key.
+func splitOperator(key string) (string, string) {
+	if key != "" && strings.Contains(withJSON+withAppend+withFile, key[len(key)-1:]) {
+		return key[:len(key)-1], key[len(key)-1:]
+	}
+
+	return key, ""
+}
+
+// parseWiths parses key=value arguments into a key-value map.
+// Values are strings, except for the forms:
+//
+//	key:=json   the value decoded from JSON, e.g. replicas:=3, debug:=true or hosts:='["a","b"]'
+//	key+=value  value appended to the list in key, which starts empty unless an earlier argument set it
+//	key@=path   the content of the file at path
+//	key=-       the content of stdin, which may be read once and is not available when stdin is nil
+//
+// A trailing newline is removed from content read from files and stdin.
+func parseWiths(keyValues []string, stdin io.Reader) (map[string]any, error) {
+	var errs []error
+
+	out := make(map[string]any)
+	read := false
+
+	for _, keyValue := range keyValues {
+		key, value, found := strings.Cut(keyValue, "=")
+
+		if !found {
+			errs = append(errs, fmt.Errorf("missing value: %q", keyValue))
+
+			continue
+		}
+
+		key, operator := splitOperator(key)
+
+		if key == "" {
+			errs = append(errs, fmt.Errorf("missing key: %q", keyValue))
+
+			continue
+		}
+
+		var (
+			parsed any
+			err    error
+		)
+
+		switch {
+		case operator == withJSON:
+			parsed, err = decodeJSON(value)
+		case operator == withAppend:
+			parsed, err = appendTo(out[key], value)
+		case operator == withFile:
+			parsed, err = readValue(os.ReadFile(value))
+		case value == stdinValue && stdin == nil:
+			err = errors.New("stdin is not available")
+		case value == stdinValue && read:
+			err = errors.New("stdin can only be read once")
+		case value == stdinValue:
+			parsed, err = readValue(io.ReadAll(stdin))
+			read = true
+		default:
+			parsed = value
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%q: %w", keyValue, err))
+
+			continue
+		}
+
+		out[key] = parsed
+	}
+
+	return out, errors.Join(errs...)
+}
+
+// decodeJSON decodes a single JSON value, with integral numbers as int and other numbers as float64.
+func decodeJSON(text string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if decoder.More() {
+		return nil, errors.New("invalid JSON: data after the value")
+	}
+
+	return numbers(value), nil
+}
+
+// numbers replaces the json.Number values in value by int or float64.
+func numbers(value any) any {
+	switch typed := value.(type) {
+	case json.Number:
+		if number, err := typed.Int64(); err == nil {
+			return int(number)
+		}
+
+		number, _ := typed.Float64()
+
+		return number
+	case []any:
+		for i, element := range typed {
+			typed[i] = numbers(element)
+		}
+	case map[string]any:
+		for key, element := range typed {
+			typed[key] = numbers(element)
+		}
+	}
+
+	return value
+}
+
+// appendTo appends value to the list current, which may be nil.
+func appendTo(current any, value string) (any, error) {
+	switch list := current.(type) {
+	case nil:
+		return []any{value}, nil
+	case []any:
+		return append(list, value), nil
+	default:
+		return nil, fmt.Errorf("cannot append to %T", current)
+	}
+}
+
+// readValue returns data as a string without a trailing newline.
+func readValue(data []byte, err error) (any, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	data = bytes.TrimSuffix(data, []byte("\r"))
+
+	return string(data), nil
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseWiths(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "value.txt")
+
+	if err := os.WriteFile(file, []byte("from=file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		stdin io.Reader
+		want  map[string]any
+		err   string
+	}{
+		{name: "plain", args: []string{"a=b"}, want: map[string]any{"a": "b"}},
+		{name: "value with =", args: []string{"url=http://x?a=b"}, want: map[string]any{"url": "http://x?a=b"}},
+		{name: "empty value", args: []string{"a="}, want: map[string]any{"a": ""}},
+		{name: "json number", args: []string{"n:=3", "f:=1.5"}, want: map[string]any{"n": 3, "f": 1.5}},
+		{name: "json bool", args: []string{"debug:=true"}, want: map[string]any{"debug": true}},
+		{
+			name: "json list and object",
+			args: []string{`hosts:=["a","b"]`, `opts:={"k":"v=w"}`},
+			want: map[string]any{"hosts": []any{"a", "b"}, "opts": map[string]any{"k": "v=w"}},
+		},
+		{name: "json string with =", args: []string{`s:="a=b"`}, want: map[string]any{"s": "a=b"}},
+		{name: "invalid json", args: []string{"n:=three"}, err: "invalid JSON"},
+		{name: "data after json", args: []string{"n:=1 2"}, err: "data after the value"},
+		{name: "append", args: []string{"l+=a", "l+=b=c"}, want: map[string]any{"l": []any{"a", "b=c"}}},
+		{name: "append to json list", args: []string{`l:=["a"]`, "l+=b"}, want: map[string]any{"l": []any{"a", "b"}}},
+		{name: "append to string", args: []string{"l=a", "l+=b"}, err: "cannot append to string"},
+		{name: "file", args: []string{"v@=" + file}, want: map[string]any{"v": "from=file"}},
+		{name: "missing file", args: []string{"v@=" + file + ".missing"}, err: "no such file"},
+		{name: "stdin", args: []string{"v=-"}, stdin: strings.NewReader("in\n"), want: map[string]any{"v": "in"}},
+		{name: "stdin twice", args: []string{"a=-", "b=-"}, stdin: strings.NewReader("in"), err: "only be read once"},
+		{name: "stdin not available", args: []string{"v=-"}, err: "stdin is not available"},
+		{name: "json dash is not stdin", args: []string{"n:=-1"}, want: map[string]any{"n": -1}},
+		{name: "missing value", args: []string{"a"}, err: "missing value"},
+		{name: "missing key", args: []string{"=b"}, err: "missing key"},
+		{name: "operator without key", args: []string{":=1"}, err: "missing key"},
+	}
+
+	for _, test := range tests {
+		got, err := parseWiths(test.args, test.stdin)
+
+		switch {
+		case test.err != "":
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", test.name, err)
+		case !reflect.DeepEqual(got, test.want):
+			t.Errorf("%s: got %#v, want %#v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Package diff computes line-based differences between texts.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+// maxCells bounds the size of the table compute fills for the changed lines of both texts,
+// which grows with the product of their lengths.
+const maxCells = 1 << 22
+
+// TooLarge is returned by Lines instead of a diff when the changed parts of the texts are too large to compare.
+const TooLarge = "(the changes are too large to show)\n"
+
+// kind is the kind of an edit.
+type kind byte
+
+const (
+	equal  kind = ' '
+	remove kind = '-'
+	insert kind = '+'
+)
+
+// edit is a line kept, removed from the old text or inserted into the new text.
+type edit struct {
+	kind kind
+	line string
+	// before and after are the 1-based line numbers in the old and the new text.
+	before, after int
+}
+
+// Lines returns a unified diff of before and after without file headers, or an empty string if they are equal.
+// It returns TooLarge if the changed parts of both texts are too large to compare.
+func Lines(before, after string) string {
+	edits, ok := compute(split(before), split(after))
+	if !ok {
+		return TooLarge
+	}
+
+	var builder strings.Builder
+
+	for start := 0; start < len(edits); {
+		if edits[start].kind == equal {
+			start++
+
+			continue
+		}
+
+		from := max(start-context, 0)
+		end := hunkEnd(edits, start)
+		to := min(end+context, len(edits))
+
+		writeHunk(&builder, edits[from:to])
+
+		start = to
+	}
+
+	return builder.String()
+}
+
+// hunkEnd returns the end of the changes starting at start, joining changes separated by
+// at most twice the context.
+func hunkEnd(edits []edit, start int) int {
+	end := start
+
+	for i := start; i < len(edits); i++ {
+		if edits[i].kind != equal {
+			end = i + 1
+
+			continue
+		}
+
+		if i-end >= 2*context {
+			break
+		}
+	}
+
+	return end
+}
+
+// writeHunk writes one hunk with its header.
+func writeHunk(builder *strings.Builder, hunk []edit) {
+	oldStart, newStart, oldCount, newCount := 0, 0, 0, 0
+
+	for _, edit := range hunk {
+		if edit.kind != insert {
+			oldCount++
+
+			if oldStart == 0 {
+				oldStart = edit.before
+			}
+		}
+
+		if edit.kind != remove {
+			newCount++
+
+			if newStart == 0 {
+				newStart = edit.after
+			}
+		}
+	}
+
+	fmt.Fprintf(builder, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+
+	for _, edit := range hunk {
+		fmt.Fprintf(builder, "%c%s\n", edit.kind, edit.line)
+	}
+}
+
+// compute returns the edits turning before into after, based on their longest common subsequence.
+// Lines common to the start and end of both texts are kept without comparing them.
+// It reports false if the remaining lines are too many to compare within maxCells.
+func compute(before, after []string) ([]edit, bool) {
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+
+	oldLines, newLines := before[prefix:len(before)-suffix], after[prefix:len(after)-suffix]
+
+	if (len(oldLines)+1)*(len(newLines)+1) > maxCells {
+		return nil, false
+	}
+
+	edits := make([]edit, 0, len(before)+len(after))
+
+	for i := range prefix {
+		edits = append(edits, edit{kind: equal, line: before[i], before: i + 1, after: i + 1})
+	}
+
+	for _, edit := range subsequence(oldLines, newLines) {
+		if edit.before != 0 {
+			edit.before += prefix
+		}
+
+		if edit.after != 0 {
+			edit.after += prefix
+		}
+
+		edits = append(edits, edit)
+	}
+
+	for k := suffix; k > 0; k-- {
+		i, j := len(before)-k, len(after)-k
+		edits = append(edits, edit{kind: equal, line: before[i], before: i + 1, after: j + 1})
+	}
+
+	return edits, true
+}
+
+// subsequence returns the edits turning before into after, based on their longest common subsequence.
+func subsequence(before, after []string) []edit {
+	// common[i][j] is the length of the longest common subsequence of before[i:] and after[j:].
+	common := make([][]int, len(before)+1)
+	for i := range common {
+		common[i] = make([]int, len(after)+1)
+	}
+
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	edits := make([]edit, 0, len(before)+len(after))
+
+	i, j := 0, 0
+
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			edits = append(edits, edit{kind: equal, line: before[i], before: i + 1, after: j + 1})
+			i++
+			j++
+		case j == len(after) || (i < len(before) && common[i+1][j] >= common[i][j+1]):
+			edits = append(edits, edit{kind: remove, line: before[i], before: i + 1})
+			i++
+		default:
+			edits = append(edits, edit{kind: insert, line: after[j], after: j + 1})
+			j++
+		}
+	}
+
+	return edits
+}
+
+// split returns the lines of text, without a final empty line.
+func split(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,121 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numbered returns the lines 1 to n, one per line.
+func numbered(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = strconv.Itoa(i + 1)
+	}
+
+	return lines
+}
+
+// text joins lines into a text with a trailing newline.
+func text(lines []string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// replaced returns lines with the given 1-based lines replaced by "x".
+func replaced(lines []string, numbers ...int) []string {
+	out := append([]string{}, lines...)
+	for _, n := range numbers {
+		out[n-1] = "x"
+	}
+
+	return out
+}
+
+func TestLines(t *testing.T) {
+	t.Parallel()
+
+	lines := numbered(20)
+
+	tests := map[string]struct {
+		before, after string
+		want          string
+	}{
+		"equal": {
+			before: text(lines),
+			after:  text(lines),
+			want:   "",
+		},
+		"empty before": {
+			before: "",
+			after:  "a\nb\n",
+			want:   "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		"empty after": {
+			before: "a\nb\n",
+			after:  "",
+			want:   "@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		"change with context": {
+			before: text(lines),
+			after:  text(replaced(lines, 10)),
+			want:   "@@ -7,7 +7,7 @@\n 7\n 8\n 9\n-10\n+x\n 11\n 12\n 13\n",
+		},
+		"change at the start": {
+			before: text(lines),
+			after:  text(replaced(lines, 1)),
+			want:   "@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n",
+		},
+		"insertion": {
+			before: "a\nb\n",
+			after:  "a\nnew\nb\n",
+			want:   "@@ -1,2 +1,3 @@\n a\n+new\n b\n",
+		},
+		// Changes separated by up to twice the context share a hunk.
+		"merged hunks": {
+			before: text(lines),
+			after:  text(replaced(lines, 5, 12)),
+			want: "@@ -2,14 +2,14 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n 9\n 10\n 11\n-12\n+x\n" +
+				" 13\n 14\n 15\n",
+		},
+		"separate hunks": {
+			before: text(lines),
+			after:  text(replaced(lines, 5, 13)),
+			want: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n" +
+				"@@ -10,7 +10,7 @@\n 10\n 11\n 12\n-13\n+x\n 14\n 15\n 16\n",
+		},
+	}
+
+	for name, test := range tests {
+		if got := Lines(test.before, test.after); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", name, got, test.want)
+		}
+	}
+}
+
+func TestLinesTrimsCommonLines(t *testing.T) {
+	t.Parallel()
+
+	// Far more lines than maxCells allows to compare, but only one of them changed.
+	lines := numbered(100_000)
+
+	got := Lines(text(lines), text(replaced(lines, 50_000)))
+
+	if want := "@@ -49997,7 +49997,7 @@\n"; !strings.HasPrefix(got, want) {
+		t.Errorf("got\n%s\nwant a hunk starting with %q", got, want)
+	}
+}
+
+func TestLinesTooLarge(t *testing.T) {
+	t.Parallel()
+
+	before := numbered(5000)
+	after := make([]string, len(before))
+
+	for i := range after {
+		after[i] = "changed " + before[i]
+	}
+
+	if got := Lines(text(before), text(after)); got != TooLarge {
+		t.Errorf("got %d bytes of diff, want %q", len(got), TooLarge)
+	}
+}
@@ -29,6 +29,28 @@ __slot_accept_line() {
   return $__ret
 }
 
+# Pick a value for each variable of a slot without a default, from its choices if it has any, or typed.
+# Prints the key=value arguments shell-quoted, and fails if a picker is cancelled.
+__slot_fill_vars() {
+  local slot=$1 var desc out rc
+  local -a args=()
+  while IFS=$'\t' read -r var desc; do
+    out=$(
+      slot vars "$slot" --choices "$var" | fzf \
+        --prompt="${var}> " \
+        --height=40% \
+        --layout=reverse-list \
+        --header="${desc:-${slot}: ${var}}" \
+        --print-query \
+        --bind 'enter:accept-or-print-query'
+    )
+    rc=$?
+    ((rc == 130 || rc == 2)) && return 1
+    args+=("$(printf '%q' "${var}=${out##*$'\n'}")")
+  done < <(slot vars "$slot" --output tsv | awk -F'\t' 'NR > 1 && $2 == "" && $3 == "false" { print $1 "\t" $5 }')
+  printf '%s' "${args[*]}"
+}
+
 # Ctrl-Y: run command in buffer as a slot
 slot_run_buffer() {
   local buf=${READLINE_LINE//$'\n'/ }
@@ -41,14 +63,14 @@ slot_run_buffer() {
   READLINE_POINT=0
 }
 
-# Ctrl-X: show menu from `slot ls --tsv`
+# Ctrl-X: show menu from `slot ls --output tsv`
 slot_pick_and_run() {
   set -o pipefail
   local out key choice
-  local name tags cmd
+  local name args qname
 
   out=$(
-    slot ls --tsv | fzf \
+    slot ls --output tsv | fzf \
       --prompt="slot> " \
       --height=40% \
       --layout=reverse-list \
@@ -58,7 +80,7 @@ slot_pick_and_run() {
       --nth=1,2,3,4 \
       --with-nth=1,3,4 \
       --tabstop=16 \
-      --preview 'printf "%s\n" {2} | sed -e "s/\\^J/\n/g" -e "s/\\\\n/\n/g"' \
+      --preview 'slot show {1}' \
       --preview-window=25% \
       --bind 'ctrl-r:toggle-preview' \
       --style=full \
@@ -70,15 +92,20 @@ slot_pick_and_run() {
   [[ -z $choice || $choice = "$key" ]] && return
 
 
-  IFS=$'\t' read -r name cmd _ _ <<<"$choice"
-  cmd=${cmd//\\n/$'\n'}
-  cmd=${cmd//^J/$'\n'}
+  IFS=$'\t' read -r name _ <<<"$choice"
+
+  if [[ $key != btab ]]; then
+    args=$(__slot_fill_vars "$name") || return
+  fi
+
+  # The name and the shell-quoted args are evaluated as shell words, so the name is quoted as well.
+  printf -v qname '%q' "$name"
 
   case $key in
-    enter) __slot_accept_line "slot run -y ${name}"; READLINE_LINE=; READLINE_POINT=0; return ;;
-    tab)   READLINE_LINE="slot run -y ${name}" ;;
-    btab)  READLINE_LINE="${cmd}" ;;
-    ctrl-space) READLINE_LINE="$(slot render ${name})" ;;
+    enter) __slot_accept_line "slot run -y ${qname}${args:+ $args}"; READLINE_LINE=; READLINE_POINT=0; return ;;
+    tab)   READLINE_LINE="slot run -y ${qname}${args:+ $args}" ;;
+    btab)  READLINE_LINE="$(slot show "$name" --format '{{.Cmd}}')" ;;
+    ctrl-space) READLINE_LINE="$(eval "slot render --inline --exec ${qname} ${args}")" ;;
   esac
 
   READLINE_POINT=${#READLINE_LINE}
@@ -18,7 +18,7 @@ slot() {
 
     # capture stdout from the real 'slot' command
     local rendered rc
-    rendered="$(command slot render "${passthru[@]}")"
+    rendered="$(command slot render --inline --exec "${passthru[@]}")"
     rc=$?
 
     if (( rc != 0 )); then
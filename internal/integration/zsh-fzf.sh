@@ -1,6 +1,32 @@
 # slot key-bindings for Ctrl-X (using fzf) and Ctrl-Z
 zmodload zsh/zle
 
+# Pick a value for each variable of a slot without a default, from its choices if it has any, or typed.
+# Prints the key=value arguments shell-quoted, and fails if a picker is cancelled.
+__slot_fill_vars() {
+  emulate -L zsh
+  local slot=$1 var desc out rc pair
+  local -a args
+
+  while IFS=$'\t' read -r var desc; do
+    out=$(
+      slot vars "$slot" --choices "$var" | fzf \
+        --prompt="${var}> " \
+        --height=40% \
+        --layout=reverse-list \
+        --header="${desc:-${slot}: ${var}}" \
+        --print-query \
+        --bind 'enter:accept-or-print-query'
+    )
+    rc=$?
+    (( rc == 130 || rc == 2 )) && return 1
+    pair="${var}=${out##*$'\n'}"
+    args+=("${(q)pair}")
+  done < <(slot vars "$slot" --output tsv | awk -F'\t' 'NR > 1 && $2 == "" && $3 == "false" { print $1 "\t" $5 }')
+
+  print -rn -- "${args[*]}"
+}
+
 # Ctrl-Y: run command in buffer as a slot
 slot-run-buffer() {
   emulate -L zsh
@@ -18,16 +44,16 @@ slot-run-buffer() {
 zle -N slot-run-buffer
 bindkey '^Z' slot-run-buffer
 
-# Ctrl-X: show menu from `slot ls --tsv`
+# Ctrl-X: show menu from `slot ls --output tsv`
 slot-pick-and-run() {
   emulate -L zsh
   set -o pipefail
   local out key choice
   local -a fields
-  local name cmd
+  local name args
 
   out=$(
-    slot ls --tsv | fzf \
+    slot ls --output tsv | fzf \
       --prompt="slot> " \
       --height=40% \
       --layout=reverse-list \
@@ -37,7 +63,7 @@ slot-pick-and-run() {
       --nth=1,2,3,4 \
       --with-nth=1,3,4 \
       --tabstop=16 \
-      --preview 'printf "%s\n" {2} | sed -e "s/\\^J/\n/g" -e "s/\\\\n/\n/g"' \
+      --preview 'slot show {1}' \
       --preview-window=25% \
       --bind 'ctrl-r:toggle-preview' \
       --style=full \
@@ -50,14 +76,17 @@ slot-pick-and-run() {
 
   fields=("${(@ps:\t:)choice}")
   name=${fields[1]}
-  cmd=${fields[2]//\\n/$'\n'}
-  cmd=${cmd//^J/$'\n'}
 
+  if [[ $key != btab ]]; then
+    args=$(__slot_fill_vars $name) || { zle reset-prompt; return }
+  fi
+
+  # The name and the shell-quoted args are evaluated as shell words, so the name is quoted as well.
   case $key in
-    enter) BUFFER="slot run -y ${name}"; zle accept-line; return ;;
-    tab)   BUFFER="slot run -y ${name}" ;;
-    btab)  BUFFER="${cmd}" ;;
-    ctrl-space) BUFFER="$(slot render ${name})" ;;
+    enter) BUFFER="slot run -y ${(q)name}${args:+ $args}"; zle accept-line; return ;;
+    tab)   BUFFER="slot run -y ${(q)name}${args:+ $args}" ;;
+    btab)  BUFFER="$(slot show "$name" --format '{{.Cmd}}')" ;;
+    ctrl-space) BUFFER="$(eval "slot render --inline --exec ${(q)name} ${args}")" ;;
   esac
 
   CURSOR=${#BUFFER}
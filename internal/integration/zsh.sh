@@ -17,7 +17,7 @@ slot() {
     done
 
     local rendered rc
-    rendered=$(command slot render "${passthru[@]}")
+    rendered=$(command slot render --inline --exec "${passthru[@]}")
     rc=$?
 
     if (( rc != 0 )); then
@@ -0,0 +1,70 @@
+// Package prompt asks for values interactively, line by line.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Prompter reads answers from a reader and writes questions to a writer.
+type Prompter struct {
+	reader *bufio.Reader
+	writer io.Writer
+}
+
+// New creates a Prompter reading from in and writing prompts to out.
+func New(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{
+		reader: bufio.NewReader(in),
+		writer: out,
+	}
+}
+
+// Terminal opens the terminal for reading answers, /dev/tty or CONIN$ on Windows,
+// so that prompts work while stdin is piped.
+func Terminal() (*os.File, error) {
+	name := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		name = "CONIN$"
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("opening the terminal for prompts: %w", err)
+	}
+
+	return file, nil
+}
+
+// Ask shows the label with its default value and returns the entered line,
+// or the default when the line is empty.
+func (p *Prompter) Ask(label, def string) (string, error) {
+	if def != "" {
+		label = fmt.Sprintf("%s [%s]", label, def)
+	}
+
+	if _, err := fmt.Fprintf(p.writer, "%s: ", label); err != nil {
+		return "", err
+	}
+
+	line, err := p.reader.ReadString('\n')
+
+	switch {
+	case errors.Is(err, io.EOF) && line == "":
+		return "", fmt.Errorf("no input for %q", label)
+	case err != nil && !errors.Is(err, io.EOF):
+		return "", err
+	}
+
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return def, nil
+	}
+
+	return line, nil
+}
@@ -0,0 +1,159 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	sprig "github.com/go-task/slim-sprig/v3"
+
+	"github.com/idelchi/slot/internal/shell"
+)
+
+// autoquote is the function ApplyQuoted appends to every action.
+const autoquote = "autoquote"
+
+// quoting are the functions whose output is not quoted again by ApplyQuoted.
+var quoting = []string{"raw", "shquote", "shjoin", "psquote", "fishquote", autoquote}
+
+// PreQuoted are the variables whose values are already quoted for POSIX shells,
+// which ApplyQuoted leaves as they are when used on their own, as in {{.CLI_ARGS}}.
+var PreQuoted = []string{"CLI_ARGS"}
+
+// funcs returns the functions available to templates: slim-sprig and the shell quoting helpers.
+func funcs() template.FuncMap {
+	funcs := sprig.FuncMap()
+
+	funcs["shquote"] = quoteWith(shell.Quote)
+	funcs["shjoin"] = shjoin
+	funcs["psquote"] = quoteWith(shell.QuotePowerShell)
+	funcs["fishquote"] = quoteWith(shell.QuoteFish)
+	funcs["raw"] = func(value any) any { return value }
+	funcs[autoquote] = quoteWith(shell.Quote)
+
+	return funcs
+}
+
+// quoteWith returns a template function quoting the text of any value with quote.
+func quoteWith(quote func(string) string) func(any) string {
+	return func(value any) string {
+		return quote(fmt.Sprint(value))
+	}
+}
+
+// shjoin quotes each element of a list for POSIX shells and joins them with spaces.
+func shjoin(list any) (string, error) {
+	var words []string
+
+	switch list := list.(type) {
+	case []string:
+		words = list
+	case []any:
+		for _, word := range list {
+			words = append(words, fmt.Sprint(word))
+		}
+	default:
+		return "", fmt.Errorf("shjoin: expected a list, got %T", list)
+	}
+
+	return shell.Join(words), nil
+}
+
+// ApplyQuoted executes a template like Apply, but passes the output of every action through quote,
+// unless any command of the action's pipeline calls raw or one of the quoting functions,
+// or the action only outputs one of the PreQuoted variables.
+func ApplyQuoted(templateString string, variables map[string]any, quote func(string) string) (string, error) {
+	template, err := Parse(templateString)
+	if err != nil {
+		return "", err
+	}
+
+	template.Funcs(map[string]any{autoquote: quoteWith(quote)})
+
+	for _, tmpl := range template.Templates() {
+		if tmpl.Tree != nil {
+			addQuoting(tmpl.Root)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := template.Execute(&buffer, variables); err != nil {
+		return "", errToMissingKey(err)
+	}
+
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+// addQuoting appends the autoquote function to every action that produces output.
+func addQuoting(node parse.Node) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+
+		for _, child := range node.Nodes {
+			addQuoting(child)
+		}
+	case *parse.ActionNode:
+		pipe := node.Pipe
+		if len(pipe.Decl) > 0 || isQuoting(pipe) || isPreQuoted(pipe) {
+			return
+		}
+
+		pipe.Cmds = append(pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      node.Pos,
+			Args:     []parse.Node{parse.NewIdentifier(autoquote).SetPos(node.Pos)},
+		})
+	case *parse.IfNode:
+		addQuoting(node.List)
+		addQuoting(node.ElseList)
+	case *parse.RangeNode:
+		addQuoting(node.List)
+		addQuoting(node.ElseList)
+	case *parse.WithNode:
+		addQuoting(node.List)
+		addQuoting(node.ElseList)
+	}
+}
+
+// isQuoting reports whether any command of a pipeline, including parenthesized pipelines
+// in its arguments, calls raw or one of the quoting functions.
+func isQuoting(pipe *parse.PipeNode) bool {
+	for _, command := range pipe.Cmds {
+		for _, arg := range command.Args {
+			switch arg := arg.(type) {
+			case *parse.IdentifierNode:
+				if slices.Contains(quoting, arg.Ident) {
+					return true
+				}
+			case *parse.PipeNode:
+				if isQuoting(arg) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// isPreQuoted reports whether a pipeline only outputs one of the PreQuoted variables.
+func isPreQuoted(pipe *parse.PipeNode) bool {
+	if len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode:
+		return len(arg.Ident) == 1 && slices.Contains(PreQuoted, arg.Ident[0])
+	case *parse.VariableNode:
+		return len(arg.Ident) == 2 && arg.Ident[0] == "$" && slices.Contains(PreQuoted, arg.Ident[1])
+	default:
+		return false
+	}
+}
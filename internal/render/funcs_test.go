@@ -0,0 +1,139 @@
+package render
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/idelchi/slot/internal/shell"
+)
+
+func TestApplyQuoted(t *testing.T) {
+	t.Parallel()
+
+	variables := map[string]any{
+		"pattern":  "foo bar",
+		"files":    "*.go",
+		"hosts":    []any{"a b", "c"},
+		"debug":    true,
+		"command":  "=ls",
+		"CLI_ARGS": shell.Join([]string{"-i", "foo bar"}),
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{`grep {{.pattern}}`, `grep 'foo bar'`},
+		{`grep {{.pattern}} {{.files | raw}}`, `grep 'foo bar' *.go`},
+		{`grep {{raw .files}}`, `grep *.go`},
+		{`grep {{.pattern | shquote}}`, `grep 'foo bar'`},
+		{`grep {{.pattern | shquote | printf "-e %s"}}`, `grep -e 'foo bar'`},
+		{`grep {{printf "-e %s" (shquote .pattern)}}`, `grep -e 'foo bar'`},
+		{`grep {{.pattern | raw | printf "%s"}}`, `grep foo bar`},
+		{`ssh {{shjoin .hosts}}`, `ssh 'a b' c`},
+		{`{{range .hosts}}ssh {{.}}; {{end}}`, `ssh 'a b'; ssh c;`},
+		{`{{if .debug}}-v {{end}}{{$p := .pattern}}{{$p}}`, `-v 'foo bar'`},
+		{`run {{.command}}`, `run '=ls'`},
+		{`grep {{.CLI_ARGS}}`, `grep -i 'foo bar'`},
+		{`grep {{$.CLI_ARGS}}`, `grep -i 'foo bar'`},
+		{`grep {{.CLI_ARGS | printf "%s"}}`, `grep '-i '\''foo bar'\'''`},
+	}
+
+	for _, test := range tests {
+		got, err := ApplyQuoted(test.template, variables, shell.Quote)
+		if err != nil {
+			t.Errorf("%s: %v", test.template, err)
+
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("%s: got %s, want %s", test.template, got, test.want)
+		}
+	}
+}
+
+func TestApplyQuotedRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	path, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+
+	values := []string{
+		"", "foo bar", "it's", "$(echo injected)", "`echo injected`", "line\nbreak", "!", "*", "~", "=ls", "; exit 1",
+	}
+
+	for _, value := range values {
+		rendered, err := ApplyQuoted(`printf '%s' {{.value}}`, map[string]any{"value": value}, shell.Quote)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		//nolint:gosec,noctx	// The script is built from fixed test values.
+		output, err := exec.Command(path, "-c", rendered).Output()
+		if err != nil {
+			t.Errorf("%q: %v", rendered, err)
+
+			continue
+		}
+
+		if string(output) != value {
+			t.Errorf("%q rendered as %s printed %q", value, rendered, output)
+		}
+	}
+}
+
+func TestShjoin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		list any
+		want string
+	}{
+		{[]string{"-i", "foo bar"}, `-i 'foo bar'`},
+		{[]any{"a", 1, "it's"}, `a 1 'it'\''s'`},
+		{[]string{}, ``},
+	}
+
+	for _, test := range tests {
+		got, err := shjoin(test.list)
+		if err != nil {
+			t.Errorf("shjoin(%v): %v", test.list, err)
+
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("shjoin(%v) = %s, want %s", test.list, got, test.want)
+		}
+	}
+
+	if _, err := shjoin("not a list"); err == nil {
+		t.Error("shjoin of a string succeeded")
+	}
+}
+
+func TestQuotingFunctions(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		`{{shquote .value}}`:   `'a '\''b'\'''`,
+		`{{psquote .value}}`:   `'a ''b'''`,
+		`{{fishquote .value}}`: `'a \'b\''`,
+	}
+
+	for template, want := range tests {
+		got, err := Apply(template, map[string]any{"value": "a 'b'"})
+		if err != nil {
+			t.Errorf("%s: %v", template, err)
+
+			continue
+		}
+
+		if got != want {
+			t.Errorf("%s: got %s, want %s", template, got, want)
+		}
+	}
+}
@@ -4,15 +4,15 @@ package render
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
 	"text/template"
-
-	sprig "github.com/go-task/slim-sprig/v3"
+	"text/template/parse"
 )
 
 // Apply executes a Go template with provided variables, returning an error if parsing fails or variables are missing.
 func Apply(templateString string, variables map[string]any) (string, error) {
-	template, err := template.New("cmd").Funcs(sprig.FuncMap()).Option("missingkey=error").Parse(templateString)
+	template, err := Parse(templateString)
 	if err != nil {
 		return "", err
 	}
@@ -26,7 +26,112 @@ func Apply(templateString string, variables map[string]any) (string, error) {
 	return strings.TrimSpace(buffer.String()), nil
 }
 
-// errToMissingKey formats the original error from text/template to a friendler one.
+// Parse parses a command template with the functions available to Apply.
+func Parse(templateString string) (*template.Template, error) {
+	return template.New("cmd").Funcs(funcs()).Option("missingkey=error").Parse(templateString)
+}
+
+// Variables returns the top-level variables referenced by a template, in order of first use.
+// Fields accessed inside 'range' and 'with' blocks are relative to the new dot and are not reported.
+func Variables(templateString string) ([]string, error) {
+	template, err := Parse(templateString)
+	if err != nil {
+		return nil, err
+	}
+
+	collector := &collector{seen: map[string]bool{}}
+
+	for _, tmpl := range template.Templates() {
+		if tmpl.Tree != nil {
+			collector.walk(tmpl.Root, true)
+		}
+	}
+
+	return collector.names, nil
+}
+
+// collector gathers variable names while walking a template tree.
+type collector struct {
+	names []string
+	seen  map[string]bool
+}
+
+// add records a variable name once.
+func (c *collector) add(name string) {
+	if c.seen[name] {
+		return
+	}
+
+	c.seen[name] = true
+	c.names = append(c.names, name)
+}
+
+// walk visits a node, where rootDot reports whether dot still refers to the template variables.
+//
+//nolint:gocognit,cyclop	// One case per node type.
+func (c *collector) walk(node parse.Node, rootDot bool) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+
+		for _, child := range node.Nodes {
+			c.walk(child, rootDot)
+		}
+	case *parse.ActionNode:
+		c.walk(node.Pipe, rootDot)
+	case *parse.IfNode:
+		c.walk(node.Pipe, rootDot)
+		c.walk(node.List, rootDot)
+		c.walk(node.ElseList, rootDot)
+	case *parse.RangeNode:
+		c.walk(node.Pipe, rootDot)
+		c.walk(node.List, false)
+		c.walk(node.ElseList, rootDot)
+	case *parse.WithNode:
+		c.walk(node.Pipe, rootDot)
+		c.walk(node.List, false)
+		c.walk(node.ElseList, rootDot)
+	case *parse.TemplateNode:
+		c.walk(node.Pipe, rootDot)
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+
+		for _, cmd := range node.Cmds {
+			c.walk(cmd, rootDot)
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			c.walk(arg, rootDot)
+		}
+	case *parse.ChainNode:
+		c.walk(node.Node, rootDot)
+	case *parse.FieldNode:
+		if rootDot {
+			c.add(node.Ident[0])
+		}
+	case *parse.VariableNode:
+		if len(node.Ident) > 1 && node.Ident[0] == "$" {
+			c.add(node.Ident[1])
+		}
+	}
+}
+
+// MissingKeyError reports a variable used by a template that has no value.
+type MissingKeyError struct {
+	// Name is the missing variable.
+	Name string
+}
+
+// Error returns the name of the missing variable.
+func (e *MissingKeyError) Error() string {
+	return fmt.Sprintf("missing template variable: %q", e.Name)
+}
+
+// errToMissingKey turns the error from text/template for a missing variable into a *MissingKeyError.
 func errToMissingKey(err error) error {
 	message := err.Error()
 
@@ -35,6 +140,10 @@ func errToMissingKey(err error) error {
 	if strings.Contains(message, seek) {
 		_, variable, _ := strings.Cut(message, seek)
 
+		if name, err := strconv.Unquote(variable); err == nil {
+			return &MissingKeyError{Name: name}
+		}
+
 		return fmt.Errorf("missing template variable: %s", variable)
 	}
 
@@ -0,0 +1,120 @@
+// Package shell quotes values for shell command lines and runs commands through shells.
+package shell
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Quote returns s as a single word for POSIX shells, in single quotes unless it only consists of safe characters.
+// A leading = is quoted as well, as zsh expands =cmd to the path of cmd.
+func Quote(s string) string {
+	if s != "" && s[0] != '=' && strings.IndexFunc(s, unsafe) == -1 {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Join quotes each word with Quote and joins them with spaces.
+func Join(words []string) string {
+	quoted := make([]string, len(words))
+
+	for i, word := range words {
+		quoted[i] = Quote(word)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// QuotePowerShell returns s as a single-quoted PowerShell string.
+func QuotePowerShell(s string) string {
+	// PowerShell also treats typographic single quotes as quotes.
+	return "'" + strings.NewReplacer("'", "''", "\u2018", "\u2018\u2018", "\u2019", "\u2019\u2019",
+		"\u201a", "\u201a\u201a", "\u201b", "\u201b\u201b").Replace(s) + "'"
+}
+
+// QuoteFish returns s as a single-quoted fish string.
+func QuoteFish(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// Quoter returns the function quoting values for shell, which is given as in Command.
+func Quoter(shell string) (func(string) string, error) {
+	fields := strings.Fields(shell)
+	if len(fields) == 0 {
+		return nil, errors.New("empty shell")
+	}
+
+	switch name(fields[0]) {
+	case "pwsh", "powershell":
+		return QuotePowerShell, nil
+	case "fish":
+		return QuoteFish, nil
+	case "cmd":
+		return nil, errors.New("quoting is not supported for cmd")
+	default:
+		return Quote, nil
+	}
+}
+
+// unsafe reports whether r needs quoting in POSIX shells.
+func unsafe(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	case strings.ContainsRune("@%+=:,./_-", r):
+		return false
+	default:
+		return true
+	}
+}
+
+// Default returns the shell to run commands with: $SHELL, or a platform default.
+func Default() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+
+	if runtime.GOOS == "windows" {
+		return cmp.Or(os.Getenv("COMSPEC"), "cmd")
+	}
+
+	return "sh"
+}
+
+// Command returns the command running script with shell, which may include arguments such as "bash -e".
+// The flag introducing the script depends on the shell: -c for POSIX shells and fish,
+// -Command for PowerShell and /C for cmd.
+func Command(ctx context.Context, shell, script string) (*exec.Cmd, error) {
+	fields := strings.Fields(shell)
+	if len(fields) == 0 {
+		return nil, errors.New("empty shell")
+	}
+
+	//nolint:gosec	// The shell and the script are chosen by the user.
+	return exec.CommandContext(ctx, fields[0], append(fields[1:], flag(fields[0]), script)...), nil
+}
+
+// flag returns the flag that makes shell run the script given as the next argument.
+func flag(shell string) string {
+	switch name(shell) {
+	case "pwsh", "powershell":
+		return "-Command"
+	case "cmd":
+		return "/C"
+	default:
+		return "-c"
+	}
+}
+
+// name returns the lower-case name of a shell program without directory and extension.
+func name(shell string) string {
+	return strings.ToLower(strings.TrimSuffix(filepath.Base(shell), filepath.Ext(shell)))
+}
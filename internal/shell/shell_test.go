@@ -0,0 +1,183 @@
+package shell_test
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/idelchi/slot/internal/shell"
+)
+
+// hostile are values that break or inject into commands when inserted unquoted.
+var hostile = []string{
+	"",
+	"plain",
+	"foo bar",
+	"it's",
+	"'",
+	"''",
+	`"double"`,
+	"$(echo injected)",
+	"`echo injected`",
+	"${HOME}",
+	"$HOME",
+	"line\nbreak",
+	"trailing\n",
+	"tab\there",
+	"!",
+	"!!",
+	"hist!ory",
+	"*",
+	"*.go",
+	"?",
+	"[abc]",
+	"{a,b}",
+	"~",
+	"~root",
+	"~/file",
+	"=ls",
+	"=",
+	"a=b",
+	"; rm -rf /",
+	"a && b",
+	"a | b",
+	"> out",
+	"#comment",
+	`back\slash`,
+	"-n",
+	"unicode ✓",
+}
+
+func TestQuoteRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, program := range []string{"bash", "zsh", "sh"} {
+		path, err := exec.LookPath(program)
+		if err != nil {
+			t.Logf("%s not found, skipping", program)
+
+			continue
+		}
+
+		for _, value := range hostile {
+			// printf avoids the option and escape handling of echo.
+			script := "printf '%s' " + shell.Quote(value)
+
+			//nolint:gosec,noctx	// The script is built from fixed test values.
+			output, err := exec.Command(path, "-c", script).Output()
+			if err != nil {
+				t.Errorf("%s -c %q: %v", program, script, err)
+
+				continue
+			}
+
+			if string(output) != value {
+				t.Errorf("%s: Quote(%q) = %s, printed %q", program, value, shell.Quote(value), output)
+			}
+		}
+	}
+}
+
+func TestJoinRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	path, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+
+	script := `for arg in ` + shell.Join(hostile) + `; do printf '%s\0' "$arg"; done`
+
+	//nolint:gosec,noctx	// The script is built from fixed test values.
+	output, err := exec.Command(path, "-c", script).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want string
+	for _, value := range hostile {
+		want += value + "\x00"
+	}
+
+	if string(output) != want {
+		t.Errorf("got %q, want %q", output, want)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"":       "''",
+		"plain":  "plain",
+		"a/b.go": "a/b.go",
+		"a=b":    "a=b",
+		"=ls":    "'=ls'",
+		"~":      "'~'",
+		"~/file": "'~/file'",
+		"it's":   `'it'\''s'`,
+		"!":      "'!'",
+		"*":      "'*'",
+	}
+
+	for value, want := range tests {
+		if got := shell.Quote(value); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestQuotePowerShell(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"":        "''",
+		"plain":   "'plain'",
+		"it's":    "'it''s'",
+		"$(evil)": "'$(evil)'",
+		"‘smart":  "'‘‘smart'",
+	}
+
+	for value, want := range tests {
+		if got := shell.QuotePowerShell(value); got != want {
+			t.Errorf("QuotePowerShell(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestQuoteFish(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"":          "''",
+		"plain":     "'plain'",
+		"it's":      `'it\'s'`,
+		`back\sl`:   `'back\\sl'`,
+		"$(evil)":   "'$(evil)'",
+		"line\nend": "'line\nend'",
+	}
+
+	for value, want := range tests {
+		if got := shell.QuoteFish(value); got != want {
+			t.Errorf("QuoteFish(%q) = %s, want %s", value, got, want)
+		}
+	}
+
+	path, err := exec.LookPath("fish")
+	if err != nil {
+		return
+	}
+
+	for _, value := range hostile {
+		//nolint:gosec,noctx	// The script is built from fixed test values.
+		output, err := exec.Command(path, "-c", "printf '%s' "+shell.QuoteFish(value)).Output()
+		if err != nil {
+			t.Errorf("fish: %q: %v", value, err)
+
+			continue
+		}
+
+		if string(output) != value {
+			t.Errorf("fish: QuoteFish(%q) printed %q", value, output)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package slot
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Choices are the valid values of a parameter: either listed, or printed one per line by a command
+// declared as {sh: <command>}.
+type Choices struct {
+	// Values are the listed choices.
+	Values []string
+	// Command prints the choices, if set.
+	Command *Dynamic
+}
+
+// IsZero reports whether no choices are declared.
+func (c Choices) IsZero() bool {
+	return len(c.Values) == 0 && c.Command == nil
+}
+
+// List returns the choices, running the command with runner if there is one.
+// Blank lines, duplicates and surrounding whitespace in the output of the command are ignored.
+func (c Choices) List(runner *Runner) ([]string, error) {
+	if c.Command == nil {
+		return c.Values, nil
+	}
+
+	output, err := runner.Run(*c.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	var choices []string
+
+	for line := range strings.Lines(output) {
+		if line = strings.TrimSpace(line); line != "" && !slices.Contains(choices, line) {
+			choices = append(choices, line)
+		}
+	}
+
+	return choices, nil
+}
+
+// String returns the listed choices separated by "|", or the command as $(command).
+func (c Choices) String() string {
+	if c.Command != nil {
+		return c.Command.String()
+	}
+
+	return strings.Join(c.Values, "|")
+}
+
+// UnmarshalYAML accepts a list of values or a command.
+func (c *Choices) UnmarshalYAML(unmarshal func(any) error) error {
+	var values []string
+	if err := unmarshal(&values); err == nil {
+		*c = Choices{Values: values}
+
+		return nil
+	}
+
+	var value any
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+
+	command, ok, err := ParseDynamic(value)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return errors.New("choices: expected a list of values or {sh: <command>}")
+	}
+
+	*c = Choices{Command: &command}
+
+	return nil
+}
+
+// MarshalYAML writes the choices as they are declared.
+func (c Choices) MarshalYAML() (any, error) {
+	return c.declared(), nil
+}
+
+// MarshalJSON writes the choices as they are declared.
+func (c Choices) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.declared())
+}
+
+// declared returns the choices in the form they are declared in.
+func (c Choices) declared() any {
+	if c.Command == nil {
+		return c.Values
+	}
+
+	command := map[string]any{"sh": c.Command.Sh}
+	if c.Command.Timeout != DefaultTimeout {
+		command["timeout"] = c.Command.Timeout.String()
+	}
+
+	return command
+}
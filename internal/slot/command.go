@@ -0,0 +1,217 @@
+package slot
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/idelchi/slot/internal/render"
+	"github.com/idelchi/slot/internal/shell"
+)
+
+// Command is a slot rendered with its template variables.
+type Command struct {
+	// Cmd is the rendered command.
+	Cmd string
+	// Env holds the rendered environment variables to run the command with.
+	Env map[string]string
+	// Dir is the rendered directory to run the command in, if any.
+	Dir string
+	// Shell is the shell to run the command with, if set by the slot.
+	Shell string
+}
+
+// Command renders the command, environment and directory of the slot with the given variables.
+// With Autoquote, values in the command are quoted for the slot's shell, or $SHELL.
+// Dynamic defaults still in variables are run with runner only once rendering uses them,
+// and checked against their parameter, if any.
+func (s Slot) Command(variables map[string]any, runner *Runner) (Command, error) {
+	if err := s.validateEnv(); err != nil {
+		return Command{}, err
+	}
+
+	variables = maps.Clone(variables)
+	pending := map[string]Dynamic{}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Vars)) {
+		dynamic, ok, err := ParseDynamic(variables[name])
+		if err != nil {
+			return Command{}, fmt.Errorf("var %q: %w", name, err)
+		}
+
+		if ok {
+			pending[name] = dynamic
+
+			delete(variables, name)
+		}
+	}
+
+	// Rendering stops at the first missing variable, so every run resolves one more dynamic default
+	// until the templates no longer use any that are missing.
+	for {
+		command, err := s.command(variables)
+
+		var missing *render.MissingKeyError
+		if !errors.As(err, &missing) {
+			return command, err
+		}
+
+		dynamic, ok := pending[missing.Name]
+		if !ok {
+			return Command{}, err
+		}
+
+		delete(pending, missing.Name)
+
+		if variables[missing.Name], err = s.resolve(missing.Name, dynamic, runner); err != nil {
+			return Command{}, err
+		}
+	}
+}
+
+// resolve runs the dynamic default of the named variable and checks its output against the variable's parameter.
+func (s Slot) resolve(name string, dynamic Dynamic, runner *Runner) (any, error) {
+	output, err := runner.Run(dynamic)
+	if err != nil {
+		return nil, fmt.Errorf("var %q: %w", name, err)
+	}
+
+	param := s.Params.Get(name)
+	if param == nil {
+		return output, nil
+	}
+
+	value, err := param.resolve(map[string]any{name: output}, runner)
+	if err != nil {
+		return nil, fmt.Errorf("parameter %q: %w", name, err)
+	}
+
+	return value, nil
+}
+
+// command renders the command, environment and directory of the slot.
+func (s Slot) command(variables map[string]any) (Command, error) {
+	cmd, err := s.render(variables)
+	if err != nil {
+		return Command{}, err
+	}
+
+	command := Command{Cmd: cmd, Shell: s.Shell}
+
+	if command.Dir, err = render.Apply(s.Dir, variables); err != nil {
+		return Command{}, fmt.Errorf("rendering dir: %w", err)
+	}
+
+	if len(s.Env) > 0 {
+		command.Env = make(map[string]string, len(s.Env))
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(s.Env)) {
+		if command.Env[key], err = render.Apply(s.Env[key], variables); err != nil {
+			return Command{}, fmt.Errorf("rendering env %q: %w", key, err)
+		}
+	}
+
+	return command, nil
+}
+
+// envName matches the names that can be exported by shells.
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateEnv checks that the names in the slot's env can be exported,
+// as they are inserted unquoted into inline commands.
+func (s Slot) validateEnv() error {
+	var errs []error
+
+	for _, key := range slices.Sorted(maps.Keys(s.Env)) {
+		if !envName.MatchString(key) {
+			errs = append(errs, fmt.Errorf("env %q: invalid name, must match %s", key, envName))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// render renders the command template, quoting values with Autoquote.
+func (s Slot) render(variables map[string]any) (string, error) {
+	if !s.Autoquote {
+		return render.Apply(s.Cmd, variables)
+	}
+
+	quote, err := shell.Quoter(cmp.Or(s.Shell, shell.Default()))
+	if err != nil {
+		return "", fmt.Errorf("autoquote: %w", err)
+	}
+
+	return render.ApplyQuoted(s.Cmd, variables, quote)
+}
+
+// Inline returns the command for POSIX shells with its directory and environment applied in a subshell,
+// or the plain command if it has neither. A failing cd aborts the subshell, and the command is run in a
+// group on its own line, so that a trailing comment, operator or heredoc in it does not swallow the end.
+func (c Command) Inline() string {
+	var steps []string
+
+	if c.Dir != "" {
+		steps = append(steps, "cd "+quoteHome(c.Dir)+" || exit 1")
+	}
+
+	if len(c.Env) > 0 {
+		assignments := make([]string, 0, len(c.Env))
+
+		for _, key := range slices.Sorted(maps.Keys(c.Env)) {
+			assignments = append(assignments, key+"="+quoteHome(c.Env[key]))
+		}
+
+		steps = append(steps, "export "+strings.Join(assignments, " "))
+	}
+
+	if len(steps) == 0 {
+		return c.Cmd
+	}
+
+	return "(" + strings.Join(steps, "; ") + "; { " + c.Cmd + "\n})"
+}
+
+// Referenced returns the variables referenced by the command, directory and environment templates of the slot.
+func (s Slot) Referenced() ([]string, error) {
+	templates := []string{s.Cmd, s.Dir}
+
+	for _, key := range slices.Sorted(maps.Keys(s.Env)) {
+		templates = append(templates, s.Env[key])
+	}
+
+	var names []string
+
+	for _, template := range templates {
+		referenced, err := render.Variables(template)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range referenced {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// quoteHome quotes a value, leaving a leading ~ for the shell to expand.
+func quoteHome(value string) string {
+	if rest, ok := strings.CutPrefix(value, "~/"); ok {
+		return "~/" + shell.Quote(rest)
+	}
+
+	if value == "~" {
+		return value
+	}
+
+	return shell.Quote(value)
+}
@@ -0,0 +1,82 @@
+package slot
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInline(t *testing.T) {
+	t.Parallel()
+
+	path, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+
+	dir := t.TempDir()
+
+	tests := []struct {
+		command Command
+		output  string
+		fails   bool
+	}{
+		{Command{Cmd: "echo plain"}, "plain\n", false},
+		{Command{Cmd: "pwd", Dir: dir}, dir + "\n", false},
+		{Command{Cmd: `echo "$A"`, Env: map[string]string{"A": "it's"}}, "it's\n", false},
+		{Command{Cmd: "echo ran", Dir: filepath.Join(dir, "missing")}, "", true},
+		{Command{Cmd: "echo ran # comment", Dir: dir}, "ran\n", false},
+		{Command{Cmd: "cat <<EOF\nheredoc\nEOF", Dir: dir}, "heredoc\n", false},
+	}
+
+	for _, test := range tests {
+		inline := test.command.Inline()
+
+		// The trailing echo shows whether the subshell kept the rest of the script intact.
+		//nolint:gosec,noctx	// The script is built from fixed test values.
+		output, err := exec.Command(path, "-c", inline+"\necho after").Output()
+		if err != nil {
+			t.Errorf("%q: %v", inline, err)
+
+			continue
+		}
+
+		got, after := strings.CutSuffix(string(output), "after\n")
+		if !after {
+			t.Errorf("%q: script did not continue after the subshell: %q", inline, output)
+		}
+
+		if test.fails && strings.Contains(got, "ran") {
+			t.Errorf("%q: command ran although the subshell failed: %q", inline, got)
+		}
+
+		if !test.fails && got != test.output {
+			t.Errorf("%q: got %q, want %q", inline, got, test.output)
+		}
+	}
+}
+
+func TestEnvNames(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"A", "_a", "PATH_2", "a1"} {
+		s := Slot{Name: "env", Cmd: "true", Env: map[string]string{name: "x"}}
+
+		if err := s.Validate(); err != nil {
+			t.Errorf("%q: %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"", "1A", "A-B", "A B", "A=B", "$(touch x)", "A;B"} {
+		s := Slot{Name: "env", Cmd: "true", Env: map[string]string{name: "x"}}
+
+		if err := s.Validate(); err == nil {
+			t.Errorf("%q: Validate succeeded", name)
+		}
+
+		if _, err := s.Command(map[string]any{}, nil); err == nil {
+			t.Errorf("%q: Command succeeded", name)
+		}
+	}
+}
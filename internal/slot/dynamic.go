@@ -0,0 +1,167 @@
+package slot
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/idelchi/slot/internal/shell"
+)
+
+// DefaultTimeout limits the run time of the command of a dynamic default without its own timeout.
+const DefaultTimeout = 5 * time.Second
+
+// Dynamic is a variable default computed by a shell command, declared in vars as {sh: <command>}.
+type Dynamic struct {
+	// Sh is the command printing the value.
+	Sh string
+	// Timeout limits the run time of the command. Defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+// ParseDynamic returns the dynamic default declared by value, and whether value declares one:
+// a mapping with a "sh" key and optionally a "timeout" key, such as {sh: git branch --show-current, timeout: 2s}.
+func ParseDynamic(value any) (Dynamic, bool, error) {
+	mapping, ok := value.(map[string]any)
+	if !ok {
+		return Dynamic{}, false, nil
+	}
+
+	sh, ok := mapping["sh"]
+	if !ok {
+		return Dynamic{}, false, nil
+	}
+
+	dynamic := Dynamic{Timeout: DefaultTimeout}
+
+	if dynamic.Sh, ok = sh.(string); !ok || strings.TrimSpace(dynamic.Sh) == "" {
+		return Dynamic{}, true, errors.New("sh: must be a non-empty command")
+	}
+
+	for key, value := range mapping {
+		switch key {
+		case "sh":
+		case "timeout":
+			timeout, err := time.ParseDuration(fmt.Sprint(value))
+			if err != nil || timeout <= 0 {
+				return Dynamic{}, true, fmt.Errorf("timeout: %q is not a positive duration", fmt.Sprint(value))
+			}
+
+			dynamic.Timeout = timeout
+		default:
+			return Dynamic{}, true, fmt.Errorf("unknown key %q, expected sh or timeout", key)
+		}
+	}
+
+	return dynamic, true, nil
+}
+
+// String returns the command in command substitution syntax, e.g. $(git branch --show-current).
+func (d Dynamic) String() string {
+	return "$(" + d.Sh + ")"
+}
+
+// MarshalText returns the command in command substitution syntax, like String, for JSON and YAML outputs.
+func (d Dynamic) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// display returns a well-formed dynamic default as its Dynamic, which prints as its command, and other values as they are.
+func display(value any) any {
+	if dynamic, ok, err := ParseDynamic(value); ok && err == nil {
+		return dynamic
+	}
+
+	return value
+}
+
+// Validate checks that the names in the slot's env are valid and the dynamic defaults in its vars are well-formed.
+func (s Slot) Validate() error {
+	errs := []error{s.validateEnv()}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Vars)) {
+		if _, _, err := ParseDynamic(s.Vars[name]); err != nil {
+			errs = append(errs, fmt.Errorf("var %q: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ErrRefused is returned by a Runner that refuses to run commands.
+var ErrRefused = errors.New("refusing to run commands")
+
+// Runner runs the commands of dynamic defaults and choices, caching their output for its lifetime.
+type Runner struct {
+	ctx     context.Context //nolint:containedctx	// A Runner lives for a single invocation.
+	program string
+	refuse  bool
+	outputs map[Dynamic]string
+}
+
+// Runner returns a Runner for the slot's commands, which run through the slot's shell, or $SHELL,
+// in the current directory. With refuse, running a command is an error instead.
+func (s Slot) Runner(ctx context.Context, refuse bool) *Runner {
+	return &Runner{
+		ctx:     ctx,
+		program: cmp.Or(s.Shell, shell.Default()),
+		refuse:  refuse,
+		outputs: map[Dynamic]string{},
+	}
+}
+
+// Run returns the output of the command without trailing newlines, running it only once.
+func (r *Runner) Run(dynamic Dynamic) (string, error) {
+	if r.refuse {
+		return "", fmt.Errorf("%w: %q", ErrRefused, dynamic.Sh)
+	}
+
+	if output, ok := r.outputs[dynamic]; ok {
+		return output, nil
+	}
+
+	output, err := dynamic.run(r.ctx, r.program)
+	if err != nil {
+		return "", err
+	}
+
+	r.outputs[dynamic] = output
+
+	return output, nil
+}
+
+// run runs the command with the shell program and returns its output without trailing newlines.
+func (d Dynamic) run(ctx context.Context, program string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	cmd, err := shell.Command(ctx, program, d.Sh)
+	if err != nil {
+		return "", err
+	}
+
+	var stderr bytes.Buffer
+
+	cmd.Stderr = &stderr
+	// Do not wait for children that keep the output open after the timeout.
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.Output()
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "", fmt.Errorf("%q timed out after %s", d.Sh, d.Timeout)
+	case err != nil && stderr.Len() > 0:
+		return "", fmt.Errorf("%q: %w: %s", d.Sh, err, strings.TrimSpace(stderr.String()))
+	case err != nil:
+		return "", fmt.Errorf("%q: %w", d.Sh, err)
+	}
+
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
@@ -1,23 +1,36 @@
 package slot
 
 import (
+	"cmp"
+	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strings"
 	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/idelchi/slot/internal/render"
 )
 
 // Common header for both outputs.
 const slotsHeader = "NAME\tCMD\tTAGS\tDESCRIPTION"
 
-// makeRecords builds rows with configurable newline handling and trimming.
-func makeRecords(slots Slots, writer io.Writer) error {
-	const newlineReplacement = "^J"
+// Header for outputs that include the source file of each slot.
+const sourcesHeader = slotsHeader + "\tSOURCE"
 
+// controlReplacer replaces newlines and tabs with caret notation so each slot fits one row.
+var controlReplacer = strings.NewReplacer("\n", "^J", "\t", "^I")
+
+// makeRecords builds rows with newlines and tabs in commands escaped.
+func makeRecords(slots Slots) [][]string {
 	records := make([][]string, 0, len(slots))
 
 	for _, slot := range slots {
-		cmd := strings.ReplaceAll(slot.Cmd, "\n", newlineReplacement)
+		cmd := controlReplacer.Replace(slot.Cmd)
 
 		records = append(records, []string{
 			slot.Name,
@@ -27,8 +40,29 @@ func makeRecords(slots Slots, writer io.Writer) error {
 		})
 	}
 
+	return records
+}
+
+// makeSourceRecords builds rows like makeRecords, with the source file of each slot appended.
+func makeSourceRecords(slots Slots) [][]string {
+	records := makeRecords(slots)
+
+	for i, slot := range slots {
+		source := filepath.ToSlash(slot.Source)
+		if slot.Shadowed {
+			source += " (shadowed)"
+		}
+
+		records[i] = append(records[i], source)
+	}
+
+	return records
+}
+
+// writeRecords writes tab-separated rows.
+func writeRecords(records [][]string, writer io.Writer) error {
 	for _, record := range records {
-		if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", record[0], record[1], record[2], record[3]); err != nil {
+		if _, err := fmt.Fprintln(writer, strings.Join(record, "\t")); err != nil {
 			return err
 		}
 	}
@@ -37,16 +71,16 @@ func makeRecords(slots Slots, writer io.Writer) error {
 }
 
 // asTable writes human-readable aligned columns via tabwriter.
-func asTable(slots Slots, writer io.Writer) error {
+func asTable(header string, records [][]string, writer io.Writer) error {
 	const TabSpacing = 2
 
 	tabWriter := tabwriter.NewWriter(writer, 0, 0, TabSpacing, ' ', 0)
 
-	if _, err := fmt.Fprintln(tabWriter, slotsHeader); err != nil {
+	if _, err := fmt.Fprintln(tabWriter, header); err != nil {
 		return err
 	}
 
-	if err := makeRecords(slots, tabWriter); err != nil {
+	if err := writeRecords(records, tabWriter); err != nil {
 		return err
 	}
 
@@ -54,10 +88,222 @@ func asTable(slots Slots, writer io.Writer) error {
 }
 
 // asTSV writes deterministic machine-readable TSV.
-func asTSV(slots Slots, writer io.Writer) error {
-	if _, err := fmt.Fprintln(writer, slotsHeader); err != nil {
+func asTSV(header string, records [][]string, writer io.Writer) error {
+	if _, err := fmt.Fprintln(writer, header); err != nil {
+		return err
+	}
+
+	return writeRecords(records, writer)
+}
+
+// asTemplate executes a Go template for each slot, writing one line per slot.
+// A "table " prefix aligns tab-separated columns and adds a header derived from the referenced fields.
+func asTemplate(slots Slots, format string, writer io.Writer) error {
+	format = strings.ReplaceAll(format, `\t`, "\t")
+
+	body, isTable := strings.CutPrefix(format, "table ")
+
+	tmpl, err := render.Parse(body)
+	if err != nil {
+		return fmt.Errorf("parsing format: %w", err)
+	}
+
+	// Slots vary in the vars they define, so missing keys are not an error here.
+	tmpl.Option("missingkey=zero")
+
+	output := writer
+
+	var tabWriter *tabwriter.Writer
+
+	if isTable {
+		const TabSpacing = 2
+
+		tabWriter = tabwriter.NewWriter(writer, 0, 0, TabSpacing, ' ', 0)
+		output = tabWriter
+
+		header, err := templateHeader(body)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintln(output, header); err != nil {
+			return err
+		}
+	}
+
+	for _, slot := range slots {
+		if err := tmpl.Execute(output, slot); err != nil {
+			return fmt.Errorf("executing format for slot %q: %w", slot.Name, err)
+		}
+
+		if _, err := fmt.Fprintln(output); err != nil {
+			return err
+		}
+	}
+
+	if tabWriter != nil {
+		return tabWriter.Flush()
+	}
+
+	return nil
+}
+
+// templateHeader names each tab-separated column of a format after the fields it references.
+func templateHeader(format string) (string, error) {
+	columns := strings.Split(format, "\t")
+	header := make([]string, len(columns))
+
+	for i, column := range columns {
+		fields, err := render.Variables(column)
+		if err != nil {
+			return "", fmt.Errorf("parsing format column %q: %w", column, err)
+		}
+
+		header[i] = strings.ToUpper(strings.Join(fields, " "))
+	}
+
+	return strings.Join(header, "\t"), nil
+}
+
+// asText writes a human-readable description of a single slot.
+func asText(slot Slot, writer io.Writer) error {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "name:        %s\n", slot.Name)
+
+	if slot.Source != "" {
+		fmt.Fprintf(&builder, "source:      %s\n", filepath.ToSlash(slot.Source))
+	}
+
+	if slot.Description != "" {
+		fmt.Fprintf(&builder, "description: %s\n", slot.Description)
+	}
+
+	if len(slot.Tags) > 0 {
+		fmt.Fprintf(&builder, "tags:        %s\n", strings.Join(slot.Tags, ", "))
+	}
+
+	if len(slot.Vars) > 0 {
+		builder.WriteString("vars:\n")
+
+		for _, key := range slices.Sorted(maps.Keys(slot.Vars)) {
+			fmt.Fprintf(&builder, "  %s=%v\n", key, display(slot.Vars[key]))
+		}
+	}
+
+	if slot.Dir != "" {
+		fmt.Fprintf(&builder, "dir:         %s\n", slot.Dir)
+	}
+
+	if slot.Shell != "" {
+		fmt.Fprintf(&builder, "shell:       %s\n", slot.Shell)
+	}
+
+	if len(slot.Env) > 0 {
+		builder.WriteString("env:\n")
+
+		for _, key := range slices.Sorted(maps.Keys(slot.Env)) {
+			fmt.Fprintf(&builder, "  %s=%s\n", key, slot.Env[key])
+		}
+	}
+
+	if len(slot.Params) > 0 {
+		builder.WriteString("params:\n")
+
+		for _, param := range slot.Params {
+			fmt.Fprintf(&builder, "  %s\n", describeParam(param))
+		}
+	}
+
+	builder.WriteString("cmd:\n")
+
+	for line := range strings.SplitSeq(strings.TrimRight(slot.Cmd, "\n"), "\n") {
+		if line != "" {
+			builder.WriteString("  " + line)
+		}
+
+		builder.WriteString("\n")
+	}
+
+	_, err := io.WriteString(writer, builder.String())
+
+	return err
+}
+
+// describeParam summarizes a parameter on a single line.
+func describeParam(param Param) string {
+	attributes := []string{cmp.Or(param.Type, TypeString)}
+
+	if param.Required {
+		attributes = append(attributes, "required")
+	}
+
+	if param.Default != nil {
+		attributes = append(attributes, fmt.Sprintf("default=%v", param.Default))
+	}
+
+	if !param.Choices.IsZero() {
+		attributes = append(attributes, "choices="+param.Choices.String())
+	}
+
+	if param.Pattern != "" {
+		attributes = append(attributes, "pattern="+param.Pattern)
+	}
+
+	description := fmt.Sprintf("%s (%s)", param.Name, strings.Join(attributes, ", "))
+	if param.Description != "" {
+		description += ": " + param.Description
+	}
+
+	return description
+}
+
+// sourced is a slot with its source file and shadowing, which are otherwise never marshalled to YAML.
+type sourced struct {
+	Slot `yaml:",inline"`
+
+	Source   string `json:"source,omitempty"`
+	Shadowed bool   `json:"shadowed,omitempty"`
+}
+
+// asYAML writes a slot or slots as YAML, including their source files.
+func asYAML(value any, writer io.Writer) error {
+	switch value := value.(type) {
+	case Slot:
+		return marshalYAML(sourced{Slot: value, Source: value.Source, Shadowed: value.Shadowed}, writer)
+	case Slots:
+		list := make([]sourced, 0, len(value))
+
+		for _, slot := range value {
+			list = append(list, sourced{Slot: slot, Source: slot.Source, Shadowed: slot.Shadowed})
+		}
+
+		return marshalYAML(list, writer)
+	default:
+		return fmt.Errorf("cannot render %T as YAML", value)
+	}
+}
+
+// marshalYAML writes value as YAML in the style of slot files.
+func marshalYAML(value any, writer io.Writer) error {
+	data, err := yaml.MarshalWithOptions(
+		value,
+		yaml.IndentSequence(true),
+		yaml.UseLiteralStyleIfMultiline(true),
+	)
+	if err != nil {
 		return err
 	}
 
-	return makeRecords(slots, writer)
+	_, err = writer.Write(data)
+
+	return err
+}
+
+// asJSON writes a value as indented JSON.
+func asJSON(value any, writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
 }
@@ -0,0 +1,231 @@
+package slot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// Supported parameter types.
+const (
+	// TypeString accepts any value.
+	TypeString = "string"
+	// TypeInt accepts base-10 integers.
+	TypeInt = "int"
+	// TypeBool accepts the values understood by strconv.ParseBool.
+	TypeBool = "bool"
+	// TypeEnum accepts one of the declared choices.
+	TypeEnum = "enum"
+	// TypePath accepts a path to an existing file or directory.
+	TypePath = "path"
+)
+
+// Param describes a template variable used by a slot.
+type Param struct {
+	// Name is the template variable the parameter describes.
+	Name string `json:"name"`
+	// Description explains the meaning of the parameter.
+	Description string `json:"description,omitempty"`
+	// Type is one of string, int, bool, enum or path. Defaults to string.
+	Type string `json:"type,omitempty"`
+	// Required fails rendering when the parameter has neither a value nor a default.
+	Required bool `json:"required,omitempty"`
+	// Default is used when no value is provided. A default for the same variable in the slot's vars
+	// takes precedence, and values given on the command line take precedence over both.
+	Default any `json:"default,omitempty"`
+	// Choices restricts the parameter to a set of values, listed or printed by a command.
+	Choices Choices `json:"choices,omitzero"`
+	// Pattern is a regular expression the whole value must match.
+	Pattern string `json:"pattern,omitempty"`
+
+	// pattern is Pattern compiled when the parameter is loaded.
+	pattern *regexp.Regexp
+}
+
+// UnmarshalYAML decodes the parameter and compiles its pattern, so that an invalid pattern fails loading.
+func (p *Param) UnmarshalYAML(unmarshal func(any) error) error {
+	type plain Param
+
+	if err := unmarshal((*plain)(p)); err != nil {
+		return err
+	}
+
+	pattern, err := p.compile()
+	if err != nil {
+		return fmt.Errorf("parameter %q: %w", p.Name, err)
+	}
+
+	p.pattern = pattern
+
+	return nil
+}
+
+// compile returns Pattern compiled to match whole values, or nil if there is none.
+func (p Param) compile() (*regexp.Regexp, error) {
+	if p.pattern != nil || p.Pattern == "" {
+		return p.pattern, nil
+	}
+
+	// The pattern is checked on its own first, so errors do not quote the anchors around it.
+	if _, err := regexp.Compile(p.Pattern); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", p.Pattern, err)
+	}
+
+	return regexp.Compile(`^(?:` + p.Pattern + `)$`)
+}
+
+// Params is a slice of Param structs.
+type Params []Param
+
+// Get retrieves a pointer to the parameter with the specified name, or nil if not found.
+func (p Params) Get(name string) *Param {
+	i := slices.IndexFunc(p, func(param Param) bool {
+		return param.Name == name
+	})
+	if i == -1 {
+		return nil
+	}
+
+	return &p[i]
+}
+
+// Apply validates variables against the parameters, filling in defaults and converting values
+// to their declared types in place. Commands generating choices are run with runner.
+// Dynamic defaults are left in place, to be checked by Slot.Command if rendering runs them.
+// All problems are reported together.
+func (p Params) Apply(variables map[string]any, runner *Runner) error {
+	var errs []error
+
+	seen := map[string]bool{}
+
+	for _, param := range p {
+		if param.Name == "" {
+			errs = append(errs, errors.New("parameter without name"))
+
+			continue
+		}
+
+		if seen[param.Name] {
+			errs = append(errs, fmt.Errorf("parameter %q: declared more than once", param.Name))
+
+			continue
+		}
+
+		seen[param.Name] = true
+
+		value, err := param.resolve(variables, runner)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parameter %q: %w", param.Name, err))
+
+			continue
+		}
+
+		variables[param.Name] = value
+	}
+
+	return errors.Join(errs...)
+}
+
+// resolve returns the validated and converted value of the parameter.
+func (p Param) resolve(variables map[string]any, runner *Runner) (any, error) {
+	value, ok := variables[p.Name]
+	if _, dynamic, _ := ParseDynamic(value); ok && dynamic {
+		return value, nil
+	}
+
+	if !ok {
+		value, ok = p.Default, p.Default != nil
+	}
+
+	if !ok {
+		if p.Required {
+			return nil, errors.New("required")
+		}
+
+		return p.zero()
+	}
+
+	text := fmt.Sprint(value)
+
+	if !p.Choices.IsZero() {
+		choices, err := p.Choices.List(runner)
+		if err != nil {
+			return nil, fmt.Errorf("choices: %w", err)
+		}
+
+		switch {
+		case slices.Contains(choices, text):
+		case p.Choices.Command != nil:
+			return nil, fmt.Errorf("%q is not one of the values printed by %q", text, p.Choices.Command.Sh)
+		default:
+			return nil, fmt.Errorf("%q is not one of %q", text, choices)
+		}
+	}
+
+	// Parameters built in code rather than loaded are compiled here.
+	pattern, err := p.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	if pattern != nil {
+		if !pattern.MatchString(text) {
+			return nil, fmt.Errorf("%q does not match pattern %q", text, p.Pattern)
+		}
+	}
+
+	return p.convert(value, text)
+}
+
+// convert converts the value to the parameter's type.
+func (p Param) convert(value any, text string) (any, error) {
+	switch p.Type {
+	case "", TypeString:
+		return value, nil
+	case TypeInt:
+		number, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", text)
+		}
+
+		return number, nil
+	case TypeBool:
+		boolean, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", text)
+		}
+
+		return boolean, nil
+	case TypeEnum:
+		if p.Choices.IsZero() {
+			return nil, errors.New("enum without choices")
+		}
+
+		return text, nil
+	case TypePath:
+		if _, err := os.Stat(text); err != nil {
+			return nil, fmt.Errorf("path %q does not exist", text)
+		}
+
+		return text, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q", p.Type)
+	}
+}
+
+// zero returns the zero value for the parameter's type.
+func (p Param) zero() (any, error) {
+	switch p.Type {
+	case "", TypeString, TypeEnum, TypePath:
+		return "", nil
+	case TypeInt:
+		return 0, nil
+	case TypeBool:
+		return false, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q", p.Type)
+	}
+}
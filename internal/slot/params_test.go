@@ -0,0 +1,36 @@
+package slot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestParamPattern(t *testing.T) {
+	t.Parallel()
+
+	var params Params
+
+	if err := yaml.Unmarshal([]byte("- name: tag\n  pattern: v[0-9]+\n"), &params); err != nil {
+		t.Fatal(err)
+	}
+
+	if params[0].pattern == nil {
+		t.Fatal("pattern was not compiled when loading")
+	}
+
+	if err := params.Apply(map[string]any{"tag": "v1"}, nil); err != nil {
+		t.Errorf("v1: %v", err)
+	}
+
+	// The pattern must match the whole value.
+	if err := params.Apply(map[string]any{"tag": "v1-rc"}, nil); err == nil {
+		t.Error("v1-rc: got no error")
+	}
+
+	err := yaml.Unmarshal([]byte("- name: tag\n  pattern: v[0-9\n"), &params)
+	if err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Errorf("got %v, want an invalid pattern error when loading", err)
+	}
+}
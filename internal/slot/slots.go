@@ -12,15 +12,29 @@ import (
 // Slot represents a saved command with metadata.
 type Slot struct {
 	// Name is the unique identifier for the slot.
-	Name string
+	Name string `json:"name"`
 	// Description provides a brief explanation of the slot's purpose.
 	Description string `json:"description,omitempty"`
 	// Cmd is the command template with placeholders.
-	Cmd string
-	// Vars are default template variables for this slot.
+	Cmd string `json:"cmd"`
+	// Vars are default template variables for this slot. They take precedence over the defaults of Params.
 	Vars map[string]any `json:"vars,omitempty"`
+	// Params document and validate the template variables of this slot.
+	Params Params `json:"params,omitempty"`
+	// Env holds environment variables to run the command with. Values are templates.
+	Env map[string]string `json:"env,omitempty"`
+	// Dir is the directory to run the command in. It is a template.
+	Dir string `json:"dir,omitempty"`
+	// Shell runs the command with slot exec, e.g. "bash" or "pwsh". Defaults to $SHELL.
+	Shell string `json:"shell,omitempty"`
+	// Autoquote quotes the output of every template action for the slot's shell, unless it uses raw.
+	Autoquote bool `json:"autoquote,omitempty"`
 	// Tags are optional labels for organizing slots.
 	Tags []string `json:"tags,omitempty"`
+	// Source is the file the slot was loaded from. It is never written to slot files.
+	Source string `json:"source,omitempty" yaml:"-"`
+	// Shadowed marks a slot hidden by an earlier slot of the same name. It is never written to slot files.
+	Shadowed bool `json:"shadowed,omitempty" yaml:"-"`
 }
 
 // Slots is a slice of Slot structs.
@@ -54,6 +68,27 @@ func (s Slots) Unique() Slots {
 	return out
 }
 
+// Shadow is a slot hidden by an earlier slot of the same name.
+type Shadow struct {
+	// Slot is the hidden slot.
+	Slot Slot
+	// By is the slot used instead.
+	By Slot
+}
+
+// Shadows returns the slots hidden by an earlier slot of the same name, in order.
+func (s Slots) Shadows() []Shadow {
+	var shadows []Shadow
+
+	for i, slot := range s {
+		if first := s.index(slot.Name); first != i {
+			shadows = append(shadows, Shadow{Slot: slot, By: s[first]})
+		}
+	}
+
+	return shadows
+}
+
 // Slice returns a new slots object containing slots from index 'from' to 'to'.
 func (s Slots) Slice(from, to int) Slots {
 	return s[from:to]
@@ -119,13 +154,49 @@ func (s Slots) Get(name string) *Slot {
 	return &s[i]
 }
 
-// Render outputs the slots in the specified format ("table" or "tsv") to the given writer.
+// Render outputs the slots in the specified format ("table", "tsv", "json" or "yaml") to the given writer.
 func (s Slots) Render(format string, writer io.Writer) error {
 	switch format {
 	case "table":
-		return asTable(s, writer)
+		return asTable(slotsHeader, makeRecords(s), writer)
+	case "tsv":
+		return asTSV(slotsHeader, makeRecords(s), writer)
+	case "json":
+		return asJSON(append(Slots{}, s...), writer)
+	case "yaml":
+		return asYAML(s, writer)
+	default:
+		return fmt.Errorf("unknown format: %q", format)
+	}
+}
+
+// RenderSources outputs the slots like Render, with a column for the source file of each slot
+// that marks shadowed slots.
+func (s Slots) RenderSources(format string, writer io.Writer) error {
+	switch format {
+	case "table":
+		return asTable(sourcesHeader, makeSourceRecords(s), writer)
 	case "tsv":
-		return asTSV(s, writer)
+		return asTSV(sourcesHeader, makeSourceRecords(s), writer)
+	default:
+		return s.Render(format, writer)
+	}
+}
+
+// RenderTemplate outputs each slot through a Go template, see asTemplate.
+func (s Slots) RenderTemplate(format string, writer io.Writer) error {
+	return asTemplate(s, format, writer)
+}
+
+// Render outputs the slot in the specified format ("text", "yaml" or "json") to the given writer.
+func (s Slot) Render(format string, writer io.Writer) error {
+	switch format {
+	case "text":
+		return asText(s, writer)
+	case "yaml":
+		return asYAML(s, writer)
+	case "json":
+		return asJSON(s, writer)
 	default:
 		return fmt.Errorf("unknown format: %q", format)
 	}
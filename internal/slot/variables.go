@@ -0,0 +1,98 @@
+package slot
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Header for variable outputs.
+const variablesHeader = "NAME\tDEFAULT\tBUILTIN\tREQUIRED\tDESCRIPTION"
+
+// Variable describes a template variable referenced by a slot.
+type Variable struct {
+	// Name is the name of the variable.
+	Name string `json:"name"`
+	// Default is the value used when none is given.
+	Default any `json:"default,omitempty"`
+	// HasDefault reports whether Default is set.
+	HasDefault bool `json:"-"`
+	// Builtin reports whether the variable is always provided.
+	Builtin bool `json:"builtin"`
+	// Required reports whether rendering fails without a value.
+	Required bool `json:"required"`
+	// Description is taken from the matching parameter, if any.
+	Description string `json:"description,omitempty"`
+}
+
+// Variables is a slice of Variable structs.
+type Variables []Variable
+
+// Variables returns the variables referenced by the slot's templates, see Referenced.
+func (s Slot) Variables(builtin func(name string) bool) (Variables, error) {
+	names, err := s.Referenced()
+	if err != nil {
+		return nil, err
+	}
+
+	variables := make(Variables, 0, len(names))
+
+	for _, name := range names {
+		variable := Variable{
+			Name:    name,
+			Builtin: builtin(name),
+		}
+
+		variable.Default, variable.HasDefault = s.Vars[name]
+		variable.Default = display(variable.Default)
+
+		param := s.Params.Get(name)
+		if param != nil {
+			variable.Description = param.Description
+
+			if !variable.HasDefault && param.Default != nil {
+				variable.Default, variable.HasDefault = param.Default, true
+			}
+		}
+
+		variable.Required = !variable.Builtin && !variable.HasDefault && (param == nil || param.Required)
+
+		variables = append(variables, variable)
+	}
+
+	return variables, nil
+}
+
+// Render outputs the variables in the specified format ("table", "tsv", "json" or "yaml") to the given writer.
+// Dynamic defaults are shown as $(command). Table and tsv escape newlines and tabs, see controlReplacer.
+func (v Variables) Render(format string, writer io.Writer) error {
+	records := make([][]string, 0, len(v))
+
+	for _, variable := range v {
+		def := ""
+		if variable.HasDefault {
+			def = controlReplacer.Replace(fmt.Sprint(variable.Default))
+		}
+
+		records = append(records, []string{
+			variable.Name,
+			def,
+			strconv.FormatBool(variable.Builtin),
+			strconv.FormatBool(variable.Required),
+			controlReplacer.Replace(variable.Description),
+		})
+	}
+
+	switch format {
+	case "table":
+		return asTable(variablesHeader, records, writer)
+	case "tsv":
+		return asTSV(variablesHeader, records, writer)
+	case "json":
+		return asJSON(append(Variables{}, v...), writer)
+	case "yaml":
+		return marshalYAML(append(Variables{}, v...), writer)
+	default:
+		return fmt.Errorf("unknown format: %q", format)
+	}
+}
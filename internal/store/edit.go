@@ -0,0 +1,529 @@
+package store
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// document is the line-level layout of the slots sequence in a slots file.
+type document struct {
+	// lines of the file, each including its line ending.
+	lines [][]byte
+	// indent of the sequence entries.
+	indent int
+	// dashes are the lines holding the '-' of each entry.
+	dashes []int
+	// heads are the first lines of each entry, including their leading comments.
+	heads []int
+	// tails are the lines after the last content line of each entry.
+	tails []int
+	// names are the values of the name key of each entry, or nil if it is not a single-line scalar.
+	names []*token.Token
+}
+
+// splice edits data to hold the slots and includes of file, keeping the original text of every slot
+// that is unchanged and the comments of every slot that is changed, as told by the origins of file.
+// Comments, blank lines and all other keys are preserved. Includes can only be appended.
+// It reports false if data cannot be edited in place, such as for non-empty flow-style sequences.
+func splice(data []byte, file slotsFile) ([]byte, bool, error) {
+	trailingNewline := bytes.HasSuffix(data, []byte("\n"))
+	if !trailingNewline {
+		data = append(bytes.Clone(data), '\n')
+	}
+
+	var original slotsFile
+	if err := yaml.Unmarshal(data, &original); err != nil {
+		return nil, false, err
+	}
+
+	// Without includes in data, original.Include is nil, which DeepEqual does not equal to an empty slice.
+	if len(file.Include) < len(original.Include) ||
+		(len(original.Include) > 0 && !reflect.DeepEqual(original.Include, file.Include[:len(original.Include)])) {
+		return nil, false, nil
+	}
+
+	out, ok, err := spliceSlots(data, original.Slots, file.Slots, file.origins)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+
+	if added := file.Include[len(original.Include):]; len(added) > 0 {
+		out, ok, err = appendIncludes(out, added)
+		if err != nil || !ok {
+			return nil, ok, err
+		}
+	}
+
+	if !trailingNewline {
+		out = bytes.TrimSuffix(out, []byte("\n"))
+	}
+
+	return out, true, nil
+}
+
+// spliceSlots rewrites the slots sequence of data from original to slots, where origins holds
+// the position in original of each slot, or -1 for added ones.
+func spliceSlots(data []byte, original, slots slot.Slots, origins []int) ([]byte, bool, error) {
+	const indent = 2
+
+	if len(origins) != len(slots) {
+		return nil, false, nil
+	}
+
+	file, err := parser.ParseBytes(data, parser.ParseComments)
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, ok := findKey(file, "slots")
+	if !ok {
+		return nil, false, nil
+	}
+
+	var out []byte
+
+	switch {
+	case value == nil:
+		out, err = insertEntries(data, -1, "slots", indent, slots)
+	case isEmpty(value.Value) && len(original) == 0:
+		cleared, line, ok := clearKey(data, value, "slots")
+		if !ok {
+			return nil, false, nil
+		}
+
+		out, err = insertEntries(cleared, line, "slots", indent, slots)
+	default:
+		sequence, ok := value.Value.(*ast.SequenceNode)
+		if !ok || sequence.IsFlowStyle || len(sequence.Entries) == 0 || len(sequence.Entries) != len(original) {
+			return nil, false, nil
+		}
+
+		out, err = layout(data, sequence).rewrite(original, slots, origins)
+	}
+
+	return out, err == nil, err
+}
+
+// appendIncludes appends entries to the include sequence of data, creating it if needed.
+func appendIncludes(data []byte, includes []Include) ([]byte, bool, error) {
+	const indent = 2
+
+	file, err := parser.ParseBytes(data, parser.ParseComments)
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, ok := findKey(file, "include")
+	if !ok {
+		return nil, false, nil
+	}
+
+	var out []byte
+
+	switch {
+	case value == nil:
+		out, err = insertEntries(data, -1, "include", indent, includes)
+	case isEmpty(value.Value):
+		cleared, line, ok := clearKey(data, value, "include")
+		if !ok {
+			return nil, false, nil
+		}
+
+		out, err = insertEntries(cleared, line, "include", indent, includes)
+	default:
+		sequence, ok := value.Value.(*ast.SequenceNode)
+		if !ok || sequence.IsFlowStyle || len(sequence.Entries) == 0 {
+			return nil, false, nil
+		}
+
+		doc := layout(data, sequence)
+
+		out, err = insertEntries(data, doc.tails[len(doc.tails)-1], "include", doc.indent, includes)
+	}
+
+	return out, err == nil, err
+}
+
+// findKey returns the top-level entry with the given key, or nil if there is none.
+// It reports false if the document cannot be edited in place.
+func findKey(file *ast.File, key string) (*ast.MappingValueNode, bool) {
+	if len(file.Docs) != 1 {
+		return nil, false
+	}
+
+	var values []*ast.MappingValueNode
+
+	switch body := file.Docs[0].Body.(type) {
+	case *ast.MappingNode:
+		if body.IsFlowStyle {
+			return nil, false
+		}
+
+		values = body.Values
+	case *ast.MappingValueNode:
+		values = []*ast.MappingValueNode{body}
+	case nil:
+		return nil, true
+	default:
+		return nil, false
+	}
+
+	for _, value := range values {
+		if value.Key.GetToken().Value == key {
+			return value, true
+		}
+	}
+
+	return nil, true
+}
+
+// clearKey returns data with the line of the top-level key reduced to "key:", keeping its comment,
+// and the number of that line, so that entries can be inserted after it.
+// It reports false unless the line holds only the key with an empty value, such as "key: []", and possibly a comment.
+func clearKey(data []byte, value *ast.MappingValueNode, key string) ([]byte, int, bool) {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	line := value.Key.GetToken().Position.Line
+
+	content, comment, commented := bytes.Cut(lines[line-1], []byte("#"))
+
+	switch string(bytes.Join(bytes.Fields(content), []byte(" "))) {
+	case key + ":", key + ": []":
+	default:
+		return nil, 0, false
+	}
+
+	cleared := []byte(key + ":\n")
+	if commented {
+		cleared = append([]byte(key+": #"), comment...)
+	}
+
+	lines[line-1] = cleared
+
+	return bytes.Join(lines, nil), line, true
+}
+
+// isEmpty reports whether a node is an empty value or an empty flow sequence.
+func isEmpty(node ast.Node) bool {
+	if sequence, ok := node.(*ast.SequenceNode); ok {
+		return sequence.IsFlowStyle && len(sequence.Entries) == 0
+	}
+
+	_, ok := node.(*ast.NullNode)
+
+	return ok || node == nil
+}
+
+// layout determines the line ranges of every entry in the sequence.
+func layout(data []byte, sequence *ast.SequenceNode) document {
+	doc := document{lines: bytes.SplitAfter(data, []byte("\n"))}
+
+	for _, entry := range sequence.Entries {
+		doc.dashes = append(doc.dashes, entry.Start.Position.Line-1)
+		doc.names = append(doc.names, nameValue(entry))
+	}
+
+	doc.indent = indentation(doc.lines[doc.dashes[0]])
+
+	for i, dash := range doc.dashes {
+		end := len(doc.lines)
+		if i+1 < len(doc.dashes) {
+			end = doc.dashes[i+1]
+		}
+
+		tail := dash + 1
+
+		for line := dash + 1; line < end; line++ {
+			if doc.floating(line) {
+				continue
+			}
+
+			if indentation(doc.lines[line]) <= doc.indent {
+				break
+			}
+
+			tail = line + 1
+		}
+
+		head := dash
+		for head > 0 && isComment(doc.lines[head-1]) && indentation(doc.lines[head-1]) <= doc.indent &&
+			(i == 0 || head-1 >= doc.tails[i-1]) {
+			head--
+		}
+
+		doc.heads = append(doc.heads, head)
+		doc.tails = append(doc.tails, tail)
+	}
+
+	return doc
+}
+
+// nameValue returns the value of the name key of a sequence entry, or nil if it is not a single-line scalar.
+func nameValue(entry *ast.SequenceEntryNode) *token.Token {
+	var values []*ast.MappingValueNode
+
+	switch value := entry.Value.(type) {
+	case *ast.MappingNode:
+		values = value.Values
+	case *ast.MappingValueNode:
+		values = []*ast.MappingValueNode{value}
+	}
+
+	for _, value := range values {
+		if value.Key.GetToken().Value != "name" {
+			continue
+		}
+
+		switch name := value.Value.GetToken(); name.Type {
+		case token.StringType, token.SingleQuoteType, token.DoubleQuoteType:
+			return name
+		}
+	}
+
+	return nil
+}
+
+// rewrite renders the document with its entries replaced by slots, where origins holds the entry
+// each slot was read from, or -1 for added ones. Unchanged slots keep their original text, see entry.
+func (doc document) rewrite(original, slots slot.Slots, origins []int) ([]byte, error) {
+	last := len(doc.heads) - 1
+
+	// New entries are separated like the first two entries, unless comments sit between them.
+	var gap []byte
+
+	if last > 0 {
+		gap = doc.join(doc.tails[0], doc.heads[1])
+	}
+
+	if len(bytes.TrimSpace(gap)) > 0 {
+		gap = []byte("\n")
+	}
+
+	out := doc.join(0, doc.heads[0])
+
+	for j, s := range slots {
+		i := origins[j]
+
+		entry, err := doc.entry(i, original, s)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, entry...)
+
+		separator := gap
+
+		if i != -1 && i < last {
+			separator = doc.join(doc.tails[i], doc.heads[i+1])
+		}
+
+		if j < len(slots)-1 {
+			out = append(out, separator...)
+		}
+	}
+
+	return append(out, doc.join(doc.tails[last], len(doc.lines))...), nil
+}
+
+// entry returns the text of slot s, read from entry i of original, or added if i is -1.
+// An unchanged slot keeps its original text, and a renamed one its original text with the new name.
+// Other changed slots are marshalled below the comments of their entry.
+func (doc document) entry(i int, original slot.Slots, s slot.Slot) ([]byte, error) {
+	marshalled, err := marshalEntry(s, doc.indent)
+	if err != nil || i == -1 {
+		return marshalled, err
+	}
+
+	previous, err := marshalEntry(original[i], 0)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := s
+	renamed.Name = original[i].Name
+
+	current, err := marshalEntry(renamed, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Equal(previous, current) {
+		if text, ok, err := doc.rename(i, s.Name); err != nil || ok {
+			return text, err
+		}
+	}
+
+	return append(doc.join(doc.heads[i], doc.dashes[i]), marshalled...), nil
+}
+
+// rename returns the text of entry i with the value of its name key replaced by name.
+// It reports false if the value cannot be replaced within its line.
+func (doc document) rename(i int, name string) ([]byte, bool, error) {
+	value := doc.names[i]
+	if value == nil {
+		return nil, false, nil
+	}
+
+	line, column := value.Position.Line-1, value.Position.Column-1
+	if line < doc.dashes[i] || line >= doc.tails[i] || column >= len(doc.lines[line]) {
+		return nil, false, nil
+	}
+
+	text := doc.lines[line]
+
+	end := scalarEnd(text, column)
+	if end == -1 {
+		return nil, false, nil
+	}
+
+	marshalled, err := yaml.Marshal(name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	marshalled = bytes.TrimSuffix(marshalled, []byte("\n"))
+	if bytes.Contains(marshalled, []byte("\n")) {
+		return nil, false, nil
+	}
+
+	lines := slices.Clone(doc.lines[doc.heads[i]:doc.tails[i]])
+	lines[line-doc.heads[i]] = slices.Concat(text[:column], marshalled, text[end:])
+
+	return bytes.Join(lines, nil), true, nil
+}
+
+// scalarEnd returns the end of the single-line scalar starting at start in line, before any comment,
+// or -1 if it does not end within the line.
+func scalarEnd(line []byte, start int) int {
+	switch line[start] {
+	case '\'':
+		for k := start + 1; k < len(line); k++ {
+			if line[k] != '\'' {
+				continue
+			}
+
+			// A doubled quote is an escaped quote.
+			if k+1 < len(line) && line[k+1] == '\'' {
+				k++
+
+				continue
+			}
+
+			return k + 1
+		}
+
+		return -1
+	case '"':
+		for k := start + 1; k < len(line); k++ {
+			switch line[k] {
+			case '\\':
+				k++
+			case '"':
+				return k + 1
+			}
+		}
+
+		return -1
+	}
+
+	end := len(bytes.TrimRight(line, "\r\n"))
+
+	if k := bytes.Index(line[start:end], []byte(" #")); k != -1 {
+		end = start + k
+	}
+
+	return len(bytes.TrimRight(line[:end], " \t"))
+}
+
+// join concatenates the lines in [from, to).
+func (doc document) join(from, to int) []byte {
+	return bytes.Join(doc.lines[from:to], nil)
+}
+
+// floating reports whether a line is blank or a comment at or left of the entry indentation,
+// and thus not part of an entry's content.
+func (doc document) floating(line int) bool {
+	text := doc.lines[line]
+
+	return len(bytes.TrimSpace(text)) == 0 || (isComment(text) && indentation(text) <= doc.indent)
+}
+
+// insertEntries inserts values as sequence entries indented by indent after the first line lines of data.
+// If line is negative, the key holding the entries is appended to the document instead.
+func insertEntries[T any](data []byte, line int, key string, indent int, values []T) ([]byte, error) {
+	if len(values) == 0 {
+		return data, nil
+	}
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+
+	if line < 0 {
+		lines = append(lines, []byte(key+":\n"))
+		line = len(lines)
+	}
+
+	out := bytes.Join(lines[:line], nil)
+
+	for _, value := range values {
+		entry, err := marshalEntry(value, indent)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, entry...)
+	}
+
+	return append(out, bytes.Join(lines[line:], nil)...), nil
+}
+
+// marshalEntry marshals a value as a sequence entry indented by indent spaces.
+func marshalEntry(value any, indent int) ([]byte, error) {
+	data, err := yaml.MarshalWithOptions(
+		[]any{value},
+		yaml.IndentSequence(true),
+		yaml.UseLiteralStyleIfMultiline(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	prefix := bytes.Repeat([]byte(" "), indent)
+	marshalled := indentation(lines[0])
+
+	var out []byte
+
+	for _, line := range lines {
+		switch {
+		case len(line) == 0:
+		case len(bytes.TrimSpace(line)) == 0:
+			out = append(out, '\n')
+		default:
+			out = append(out, prefix...)
+			out = append(out, line[min(marshalled, indentation(line)):]...)
+		}
+	}
+
+	return out, nil
+}
+
+// indentation returns the number of leading spaces of a line.
+func indentation(line []byte) int {
+	return len(line) - len(bytes.TrimLeft(line, " "))
+}
+
+// isComment reports whether a line only holds a comment.
+func isComment(line []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(line), []byte("#"))
+}
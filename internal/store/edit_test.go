@@ -0,0 +1,145 @@
+package store
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/idelchi/slot/internal/slot"
+)
+
+func TestSaveKeepsComments(t *testing.T) {
+	tests := map[string]string{
+		"block": "# my slots\nslots:\n  # greeting\n  - name: hello\n    cmd: echo hello # inline\n",
+		"empty": "# my slots\nslots: [] # none yet\n",
+		"null":  "# my slots\nslots: # none yet\n",
+	}
+
+	for name, content := range tests {
+		store := newTestStore(t, content)
+
+		if _, err := store.Save("", slot.Slot{Name: "added", Cmd: "true"}, false); err != nil {
+			t.Errorf("%s: %v", name, err)
+
+			continue
+		}
+
+		data, err := os.ReadFile(store.Path())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+			line = strings.Replace(line, "slots: []", "slots:", 1)
+
+			if !strings.Contains(string(data), line) {
+				t.Errorf("%s: line %q lost:\n%s", name, line, data)
+			}
+		}
+
+		if slots, err := store.Load(); err != nil || !slots.Exists("added") {
+			t.Errorf("%s: added slot not loaded: %v\n%s", name, err, data)
+		}
+	}
+}
+
+func TestSaveIncludeIntoEmptyFlowSequence(t *testing.T) {
+	store := newTestStore(t, "include: [] # shared files\nslots: []\n")
+	team := store.Path() + ".team.yaml"
+
+	if _, err := store.Save(team, slot.Slot{Name: "team", Cmd: "true"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), "include: # shared files\n") {
+		t.Errorf("include comment lost:\n%s", data)
+	}
+
+	if slots, err := store.Load(); err != nil || !slots.Exists("team") {
+		t.Errorf("slot in the new include not loaded: %v\n%s", err, data)
+	}
+}
+
+func TestSaveIncludeIntoFileWithoutIncludes(t *testing.T) {
+	store := newTestStore(t, "# my slots\nslots:\n  - name: hello\n    cmd: echo hello\n")
+	team := store.Path() + ".team.yaml"
+
+	if _, err := store.Save(team, slot.Slot{Name: "team", Cmd: "true"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if slots, err := store.Load(); err != nil || !slots.Exists("team") || !slots.Exists("hello") {
+		t.Errorf("got error %v, want the slots of both files", err)
+	}
+}
+
+func TestWriteRefusesLayoutsLosingComments(t *testing.T) {
+	content := "# my slots\nslots: [{name: hello, cmd: echo hello}]\n"
+	store := newTestStore(t, content)
+
+	if _, err := store.Save("", slot.Slot{Name: "added", Cmd: "true"}, false); err == nil ||
+		!strings.Contains(err.Error(), "block-style") {
+		t.Errorf("got error %v, want a refusal to rewrite the file", err)
+	}
+
+	if data, _ := os.ReadFile(store.Path()); string(data) != content {
+		t.Errorf("file changed:\n%s", data)
+	}
+}
+
+func TestRenameKeepsComments(t *testing.T) {
+	content := "slots:\n  # first\n  - name: a\n    cmd: echo a\n  # second\n  - name: b # trailing\n" +
+		"    cmd: echo b # inline\n  # after b\n  - name: 'c' # quoted\n    cmd: echo c\n"
+
+	store := newTestStore(t, content)
+
+	for _, names := range [][2]string{{"b", "bb"}, {"c", "c d"}} {
+		slots, err := store.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s := *slots.Get(names[0])
+		s.Name = names[1]
+
+		if _, err := store.Replace(names[0], s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.NewReplacer("name: b ", "name: bb ", "name: 'c' ", "name: c d ").Replace(content)
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestReplaceKeepsCommentsOfChangedSlots(t *testing.T) {
+	content := "slots:\n  - name: a\n    cmd: echo a\n  # second\n  - name: b\n    cmd: echo b\n  # after b\n" +
+		"  - name: c\n    cmd: echo c\n"
+
+	store := newTestStore(t, content)
+
+	if _, err := store.Replace("b", slot.Slot{Name: "renamed", Cmd: "echo changed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Replace(content, "name: b\n    cmd: echo b", "name: renamed\n    cmd: echo changed", 1)
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+}
@@ -0,0 +1,252 @@
+package store
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitFile is the file included from a git repository when the include has no path.
+const GitFile = "slots.yaml"
+
+// CacheDir returns the directory git repositories of includes are cached in.
+func CacheDir() (string, error) {
+	slotsFile, err := DefaultSlotsFile()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(slotsFile), "cache", "git"), nil
+}
+
+// repository is the cached checkout of the git repository of an include.
+type repository struct {
+	url string
+	ref string
+	dir string
+}
+
+// String describes the repository as url@ref.
+func (repo repository) String() string {
+	if repo.ref == "" {
+		return repo.url
+	}
+
+	return repo.url + "@" + repo.ref
+}
+
+// repository returns the cached checkout for a git include declared by this store.
+// Local repository paths are resolved relative to the declaring file.
+// URLs and refs starting with '-' are refused, as git would parse them as options.
+func (store Store) repository(include Include) (repository, error) {
+	url, err := expand(include.Git)
+	if err != nil {
+		return repository{}, fmt.Errorf("git include %q in %q: %w", include.Git, filepath.ToSlash(store.Path()), err)
+	}
+
+	for _, value := range []string{url, include.Ref} {
+		if strings.HasPrefix(value, "-") {
+			return repository{}, fmt.Errorf(
+				"git include %q in %q: %q must not start with '-'",
+				include.Git,
+				filepath.ToSlash(store.Path()),
+				value,
+			)
+		}
+	}
+
+	if isLocalRepository(url) && !filepath.IsAbs(url) {
+		url = filepath.Join(filepath.Dir(store.Path()), url)
+	}
+
+	cache, err := CacheDir()
+	if err != nil {
+		return repository{}, err
+	}
+
+	key := sha256.Sum256([]byte(url + "\x00" + include.Ref))
+
+	return repository{url: url, ref: include.Ref, dir: filepath.Join(cache, hex.EncodeToString(key[:8]))}, nil
+}
+
+// resolveGit returns the stores for a git include, cloning its repository if it is not cached yet.
+// Cached repositories are used as they are, so loading works offline; see Update.
+func (store Store) resolveGit(include Include) ([]Store, error) {
+	path := cmp.Or(include.Path, GitFile)
+	if !filepath.IsLocal(path) {
+		return nil, fmt.Errorf("git include %q in %q: path %q leaves the repository", include.Git, filepath.ToSlash(store.Path()), path)
+	}
+
+	repo, err := store.repository(include)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(repo.dir); errors.Is(err, os.ErrNotExist) {
+		if err := repo.fetch(); err != nil {
+			if include.Optional {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+	}
+
+	return store.resolveInclude(Include{Path: filepath.Join(repo.dir, path), Optional: include.Optional})
+}
+
+// Update fetches the git repositories of all includes again and returns their descriptions.
+func (store Store) Update() ([]string, error) {
+	store, err := store.clean()
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []string
+
+	seen := map[string]bool{}
+
+	_, err = store.traverse(func(store Store, file slotsFile, _ scope) (bool, error) {
+		for _, include := range file.Include {
+			if include.Git == "" {
+				continue
+			}
+
+			repo, err := store.repository(include)
+			if err != nil {
+				return false, err
+			}
+
+			if seen[repo.dir] {
+				continue
+			}
+
+			seen[repo.dir] = true
+
+			if err := repo.fetch(); err != nil {
+				return false, err
+			}
+
+			updated = append(updated, repo.String())
+		}
+
+		return false, nil
+	})
+
+	return updated, err
+}
+
+// fetch checks out the ref of the repository, cloning it first if it is not cached yet.
+// New clones are prepared in a temporary directory, so an interrupted clone never ends up in the cache.
+func (repo repository) fetch() error {
+	if _, err := os.Stat(repo.dir); err == nil {
+		return repo.checkout(repo.dir)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(repo.dir), 0o750); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(repo.dir), ".clone-*")
+	if err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	defer os.RemoveAll(tmp)
+
+	if err := git("init", "--quiet", tmp); err != nil {
+		return err
+	}
+
+	if err := git("-C", tmp, "remote", "add", "--end-of-options", "origin", repo.url); err != nil {
+		return err
+	}
+
+	if err := repo.checkout(tmp); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, repo.dir); err != nil {
+		// Another process may have cloned the repository in the meantime.
+		if _, statErr := os.Stat(repo.dir); statErr == nil {
+			return nil
+		}
+
+		return fmt.Errorf("caching %s: %w", repo, err)
+	}
+
+	return nil
+}
+
+// checkout fetches the ref of the repository into dir and checks it out.
+func (repo repository) checkout(dir string) error {
+	ref := repo.ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	if err := git("-C", dir, "fetch", "--quiet", "--force", "--tags", "--end-of-options", "origin", ref); err != nil {
+		return fmt.Errorf("fetching %s: %w", repo, err)
+	}
+
+	if err := git("-C", dir, "checkout", "--quiet", "--force", "--detach", "FETCH_HEAD"); err != nil {
+		return fmt.Errorf("checking out %s: %w", repo, err)
+	}
+
+	return nil
+}
+
+// git runs git without prompting for credentials, reporting its error output on failure.
+// Values from include lists must follow --end-of-options, so they are never parsed as options.
+func git(args ...string) error {
+	//nolint:gosec,noctx	// Arguments come from the include list; the store has no context.
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stderr bytes.Buffer
+
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// isLocalRepository reports whether url is a path rather than a URL or an scp-like address.
+func isLocalRepository(url string) bool {
+	if filepath.IsAbs(url) {
+		return true
+	}
+
+	if strings.Contains(url, "://") {
+		return false
+	}
+
+	// scp-like addresses such as git@host:repo.git have a colon before any slash.
+	colon := strings.Index(url, ":")
+
+	return colon == -1 || strings.Contains(url[:colon], "/")
+}
+
+// writable returns an error if the store is a cached copy of a git repository.
+func (store Store) writable() error {
+	cache, err := CacheDir()
+	if err != nil {
+		return nil //nolint:nilerr	// Without a cache directory no file can be cached.
+	}
+
+	if relative, err := filepath.Rel(cache, store.Path()); err == nil && filepath.IsLocal(relative) {
+		return fmt.Errorf("%q is a cached copy of a git repository and cannot be modified", filepath.ToSlash(store.Path()))
+	}
+
+	return nil
+}
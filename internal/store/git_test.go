@@ -0,0 +1,195 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// gitRepository is a bare repository with a work tree to commit slots files from.
+type gitRepository struct {
+	bare string
+	work string
+}
+
+// newGitRepository creates a bare repository with a main branch in a temporary directory.
+func newGitRepository(t *testing.T) gitRepository {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	for key, value := range map[string]string{
+		"GIT_AUTHOR_NAME":     "slot",
+		"GIT_AUTHOR_EMAIL":    "slot@example.com",
+		"GIT_COMMITTER_NAME":  "slot",
+		"GIT_COMMITTER_EMAIL": "slot@example.com",
+		"GIT_CONFIG_NOSYSTEM": "1",
+	} {
+		t.Setenv(key, value)
+	}
+
+	dir := t.TempDir()
+	repo := gitRepository{bare: filepath.Join(dir, "remote.git"), work: filepath.Join(dir, "work")}
+
+	runGit(t, "init", "--quiet", "--bare", "--initial-branch=main", repo.bare)
+	runGit(t, "init", "--quiet", "--initial-branch=main", repo.work)
+	runGit(t, "-C", repo.work, "remote", "add", "origin", repo.bare)
+
+	return repo
+}
+
+// commit commits a slots file with a slot named hello running cmd and pushes it with its tags.
+func (repo gitRepository) commit(t *testing.T, cmd string, tags ...string) {
+	t.Helper()
+
+	content := fmt.Sprintf("slots:\n  - name: hello\n    cmd: %s\n", cmd)
+
+	if err := os.WriteFile(filepath.Join(repo.work, GitFile), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	runGit(t, "-C", repo.work, "add", GitFile)
+	runGit(t, "-C", repo.work, "commit", "--quiet", "-m", cmd)
+
+	for _, tag := range tags {
+		runGit(t, "-C", repo.work, "tag", tag)
+	}
+
+	runGit(t, "-C", repo.work, "push", "--quiet", "--tags", "origin", "main")
+}
+
+// runGit runs git and fails the test on errors.
+func runGit(t *testing.T, args ...string) {
+	t.Helper()
+
+	//nolint:gosec,noctx	// The arguments are fixed by the tests.
+	if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, output)
+	}
+}
+
+// cmdOf returns the command of the named slot, failing the test if it is missing.
+func cmdOf(t *testing.T, store Store, name string) string {
+	t.Helper()
+
+	slots, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := slots.Get(name)
+	if s == nil {
+		t.Fatalf("slot %q not found in %v", name, slots.Names())
+	}
+
+	return s.Cmd
+}
+
+func TestGitIncludePinnedRef(t *testing.T) {
+	repo := newGitRepository(t)
+	repo.commit(t, "echo v1", "v1")
+
+	store := newTestStore(t, fmt.Sprintf("include:\n  - git: %s\n    ref: v1\n    name: pinned\n", repo.bare))
+
+	if got := cmdOf(t, store, "pinned:hello"); got != "echo v1" {
+		t.Fatalf("got %q, want %q", got, "echo v1")
+	}
+
+	repo.commit(t, "echo v2")
+
+	if _, err := store.Update(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cmdOf(t, store, "pinned:hello"); got != "echo v1" {
+		t.Errorf("pinned ref moved: got %q, want %q", got, "echo v1")
+	}
+}
+
+func TestGitIncludeUpdate(t *testing.T) {
+	repo := newGitRepository(t)
+	repo.commit(t, "echo v1")
+
+	store := newTestStore(t, fmt.Sprintf("include:\n  - git: %s\n    ref: main\n    name: latest\n", repo.bare))
+
+	if got := cmdOf(t, store, "latest:hello"); got != "echo v1" {
+		t.Fatalf("got %q, want %q", got, "echo v1")
+	}
+
+	repo.commit(t, "echo v2")
+
+	// Loading uses the cached checkout until the next update.
+	if got := cmdOf(t, store, "latest:hello"); got != "echo v1" {
+		t.Errorf("cached checkout changed without update: got %q, want %q", got, "echo v1")
+	}
+
+	updated, err := store.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := repo.bare + "@main"; len(updated) != 1 || updated[0] != want {
+		t.Errorf("got updated %q, want [%q]", updated, want)
+	}
+
+	if got := cmdOf(t, store, "latest:hello"); got != "echo v2" {
+		t.Errorf("got %q after update, want %q", got, "echo v2")
+	}
+}
+
+func TestGitIncludeNotWritable(t *testing.T) {
+	repo := newGitRepository(t)
+	repo.commit(t, "echo v1")
+
+	store := newTestStore(t, fmt.Sprintf("include:\n  - git: %s\n    name: shared\n", repo.bare))
+
+	if _, err := store.Replace("shared:hello", slot.Slot{Name: "shared:hello", Cmd: "echo changed"}); err == nil ||
+		!strings.Contains(err.Error(), "cached copy") {
+		t.Errorf("replacing a slot of a git include: got error %v, want a refusal", err)
+	}
+
+	if _, err := store.Delete("shared:hello"); err == nil {
+		t.Error("deleting a slot of a git include succeeded")
+	}
+
+	if _, err := store.Save("shared", slot.Slot{Name: "shared:other", Cmd: "true"}, false); err == nil {
+		t.Error("saving into a git include succeeded")
+	}
+
+	if got := cmdOf(t, store, "shared:hello"); got != "echo v1" {
+		t.Errorf("cached checkout changed: got %q, want %q", got, "echo v1")
+	}
+}
+
+func TestGitIncludeRefusesOptions(t *testing.T) {
+	repo := newGitRepository(t)
+	repo.commit(t, "echo v1")
+
+	marker := filepath.Join(t.TempDir(), "injected")
+
+	for _, include := range []string{
+		fmt.Sprintf("git: %s\n    ref: --upload-pack=touch %s", repo.bare, marker),
+		fmt.Sprintf("git: --upload-pack=touch %s", marker),
+	} {
+		store := newTestStore(t, "include:\n  - "+include+"\n")
+
+		if _, err := store.Load(); err == nil || !strings.Contains(err.Error(), "must not start with '-'") {
+			t.Errorf("%s: got error %v, want a refusal", include, err)
+		}
+
+		if _, err := store.Update(); err == nil {
+			t.Errorf("%s: update succeeded", include)
+		}
+	}
+
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("the injected command ran")
+	}
+}
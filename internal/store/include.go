@@ -0,0 +1,152 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// Separator joins the name of an include with the names of its slots.
+const Separator = ":"
+
+// Include is an entry of the include list of a slots file.
+// It is written either as a plain path or as an object.
+type Include struct {
+	// Path is the file to include, relative to the including file, or to the root of the repository
+	// for git includes, where it defaults to GitFile.
+	Path string `json:"path,omitempty"`
+	// Name is an optional namespace; slots of the included file are then called "<name>:<slot>".
+	Name string `json:"name,omitempty"`
+	// Tags are added to every slot of the included file.
+	Tags []string `json:"tags,omitempty"`
+	// Optional skips the include if the file does not exist or its path uses an unset variable.
+	Optional bool `json:"optional,omitempty"`
+	// Git is the URL or path of a git repository to include Path from.
+	Git string `json:"git,omitempty"`
+	// Ref is the branch, tag or commit of the git repository to use. Defaults to its HEAD.
+	Ref string `json:"ref,omitempty"`
+	// SHA256 pins the content of the included file; loading fails if its hash differs.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// UnmarshalYAML accepts a plain path or an object.
+func (include *Include) UnmarshalYAML(unmarshal func(any) error) error {
+	var path string
+	if err := unmarshal(&path); err == nil {
+		*include = Include{Path: path}
+
+		return nil
+	}
+
+	type plain Include
+
+	return unmarshal((*plain)(include))
+}
+
+// MarshalYAML writes includes with only a path as plain paths.
+func (include Include) MarshalYAML() (any, error) {
+	onlyPath := include.Name == "" && len(include.Tags) == 0 && !include.Optional &&
+		include.Git == "" && include.SHA256 == ""
+	if onlyPath {
+		return include.Path, nil
+	}
+
+	type plain Include
+
+	return plain(include), nil
+}
+
+// errUnsetVariable is returned by expand for paths using an unset environment variable.
+var errUnsetVariable = errors.New("environment variable is not set")
+
+// expand replaces $VAR and ${VAR} with environment variables and a leading ~ with the home directory.
+func expand(path string) (string, error) {
+	var unset []string
+
+	path = os.Expand(path, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			unset = append(unset, name)
+		}
+
+		return value
+	})
+
+	if len(unset) > 0 {
+		return "", fmt.Errorf("%w: %s", errUnsetVariable, strings.Join(unset, ", "))
+	}
+
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("expanding ~: %w", err)
+		}
+
+		path = filepath.Join(home, path[1:])
+	}
+
+	return path, nil
+}
+
+// scope is the namespace and the tags applied to the slots of a file through the includes leading to it.
+type scope struct {
+	prefix string
+	tags   []string
+}
+
+// include returns the scope of a file included from this scope.
+func (s scope) include(include Include) scope {
+	child := scope{prefix: s.prefix, tags: slices.Clone(s.tags)}
+
+	if include.Name != "" {
+		child.prefix += include.Name + Separator
+	}
+
+	for _, tag := range include.Tags {
+		if !slices.Contains(child.tags, tag) {
+			child.tags = append(child.tags, tag)
+		}
+	}
+
+	return child
+}
+
+// qualify returns the slot as seen from the root store, with prefixed name and added tags.
+func (s scope) qualify(local slot.Slot) slot.Slot {
+	local.Name = s.prefix + local.Name
+	local.Tags = slices.Clone(local.Tags)
+
+	for _, tag := range s.tags {
+		if !slices.Contains(local.Tags, tag) {
+			local.Tags = append(local.Tags, tag)
+		}
+	}
+
+	return local
+}
+
+// localize reverses qualify for a slot written to a file of this scope. original is the slot it replaces,
+// if any, whose own tags are kept even when they are also added by the scope.
+func (s scope) localize(qualified slot.Slot, original *slot.Slot) (slot.Slot, error) {
+	name, ok := strings.CutPrefix(qualified.Name, s.prefix)
+	if !ok || name == "" {
+		return slot.Slot{}, fmt.Errorf("slot %q must be named %q<name> in this file", qualified.Name, s.prefix)
+	}
+
+	qualified.Name = name
+	qualified.Source = ""
+	qualified.Tags = slices.DeleteFunc(slices.Clone(qualified.Tags), func(tag string) bool {
+		return slices.Contains(s.tags, tag) && (original == nil || !slices.Contains(original.Tags, tag))
+	})
+
+	if len(qualified.Tags) == 0 {
+		qualified.Tags = nil
+	}
+
+	return qualified, nil
+}
@@ -0,0 +1,290 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// writeFiles writes the files, given by their paths relative to dir, creating their directories.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIncludesReachingAFileTwice(t *testing.T) {
+	tests := map[string]map[string]string{
+		"sibling globs": {
+			"slots.yaml": "include:\n  - ./team/*.yaml\n  - ./team/s*.yaml\n",
+		},
+		"diamond": {
+			"slots.yaml": "include:\n  - ./a.yaml\n  - ./b.yaml\n",
+			"a.yaml":     "include:\n  - ./team/shared.yaml\n",
+			"b.yaml":     "include:\n  - ./team/shared.yaml\n",
+		},
+		"globs in siblings": {
+			"slots.yaml": "include:\n  - ./a.yaml\n  - ./b.yaml\n",
+			"a.yaml":     "include:\n  - ./team/*.yaml\n",
+			"b.yaml":     "include:\n  - ./team/*.yaml\n",
+		},
+	}
+
+	for name, files := range tests {
+		store := newTestStore(t, "")
+		dir := filepath.Dir(store.Path())
+
+		files["team/shared.yaml"] = "slots:\n  - name: shared\n    cmd: true\n"
+		writeFiles(t, dir, files)
+
+		all, err := store.LoadAll()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+
+			continue
+		}
+
+		if got := all.Names(); !slices.Equal(got, []string{"shared"}) {
+			t.Errorf("%s: got slots %q, want the shared slot once", name, got)
+		}
+	}
+}
+
+func TestRecursiveInclude(t *testing.T) {
+	store := newTestStore(t, "include:\n  - ./a.yaml\n")
+
+	writeFiles(t, filepath.Dir(store.Path()), map[string]string{
+		"a.yaml": "include:\n  - ./b*.yaml\n",
+		"b.yaml": "include:\n  - ./a.yaml\n",
+	})
+
+	if _, err := store.Load(); err == nil || !strings.Contains(err.Error(), "recursive include") {
+		t.Errorf("got error %v, want a recursive include", err)
+	}
+}
+
+func TestGlobIncludeWithoutMatches(t *testing.T) {
+	store := newTestStore(t, "include:\n  - ./team/*.yaml\n")
+
+	if _, err := store.Load(); err == nil || !strings.Contains(err.Error(), "matches no files") {
+		t.Errorf("got error %v, want a glob without matches", err)
+	}
+
+	store = newTestStore(t, "include:\n  - path: ./team/*.yaml\n    optional: true\n")
+
+	if _, err := store.Load(); err != nil {
+		t.Errorf("optional glob without matches: %v", err)
+	}
+}
+
+func TestNamespacedIncludes(t *testing.T) {
+	store := newTestStore(t, "include:\n  - path: ./team.yaml\n    name: team\n    tags: [shared, ops]\n")
+
+	writeFiles(t, filepath.Dir(store.Path()), map[string]string{
+		"team.yaml": "slots:\n  - name: deploy\n    cmd: true\n    tags: [ops, prod]\n",
+	})
+
+	slots, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deploy := slots.Get("team:deploy")
+	if deploy == nil {
+		t.Fatalf("got slots %q, want team:deploy", slots.Names())
+	}
+
+	// Tags of the slot come first, and tags of the include are added once.
+	if want := []string{"ops", "prod", "shared"}; !slices.Equal(deploy.Tags, want) {
+		t.Errorf("got tags %q, want %q", deploy.Tags, want)
+	}
+
+	team := filepath.Join(filepath.Dir(store.Path()), "team.yaml")
+
+	if _, err := store.Save(team, slot.Slot{Name: "build", Cmd: "true"}, false); err == nil ||
+		!strings.Contains(err.Error(), `must be named "team:"<name>`) {
+		t.Errorf("saving without the namespace: got error %v, want a refusal", err)
+	}
+
+	added := slot.Slot{Name: "team:build", Cmd: "true", Tags: []string{"shared", "ci"}}
+	if _, err := store.Save(team, added, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file holds the local name and only the slot's own tags; those added by the include are left out.
+	if _, err := store.Replace("team:deploy", *deploy); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(team)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "slots:\n  - name: deploy\n    cmd: true\n    tags: [ops, prod]\n" +
+		"  - name: build\n    cmd: \"true\"\n    tags:\n      - ci\n"
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestScopeLocalize(t *testing.T) {
+	t.Parallel()
+
+	scope := scope{}.include(Include{Name: "team", Tags: []string{"shared"}})
+
+	tests := []struct {
+		name     string
+		slot     slot.Slot
+		original *slot.Slot
+		want     slot.Slot
+		err      bool
+	}{
+		{
+			name: "added tags are removed",
+			slot: slot.Slot{Name: "team:a", Tags: []string{"shared", "own"}},
+			want: slot.Slot{Name: "a", Tags: []string{"own"}},
+		},
+		{
+			name:     "own tags are kept",
+			slot:     slot.Slot{Name: "team:a", Tags: []string{"shared"}},
+			original: &slot.Slot{Name: "a", Tags: []string{"shared"}},
+			want:     slot.Slot{Name: "a", Tags: []string{"shared"}},
+		},
+		{
+			name: "only added tags",
+			slot: slot.Slot{Name: "team:a", Tags: []string{"shared"}},
+			want: slot.Slot{Name: "a"},
+		},
+		{name: "missing prefix", slot: slot.Slot{Name: "a"}, err: true},
+		{name: "only the prefix", slot: slot.Slot{Name: "team:"}, err: true},
+		{name: "other prefix", slot: slot.Slot{Name: "ops:a"}, err: true},
+	}
+
+	for _, test := range tests {
+		got, err := scope.localize(test.slot, test.original)
+
+		switch {
+		case test.err:
+			if err == nil {
+				t.Errorf("%s: got %+v, want an error", test.name, got)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", test.name, err)
+		case !reflect.DeepEqual(got, test.want):
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+
+	// Nested includes join their namespaces and tags.
+	nested := scope.include(Include{Name: "ops", Tags: []string{"shared", "ops"}})
+	qualified := nested.qualify(slot.Slot{Name: "a", Tags: []string{"own"}})
+
+	if qualified.Name != "team:ops:a" || !slices.Equal(qualified.Tags, []string{"own", "shared", "ops"}) {
+		t.Errorf("got %q with tags %q, want team:ops:a with tags own, shared, ops", qualified.Name, qualified.Tags)
+	}
+}
+
+// unsetenv unsets the environment variable for the duration of the test.
+func unsetenv(t *testing.T, name string) {
+	t.Helper()
+
+	// Setenv restores the variable after the test.
+	t.Setenv(name, "")
+
+	if err := os.Unsetenv(name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	home := t.TempDir()
+
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("SLOT_TEAM", "team")
+	t.Setenv("SLOT_EMPTY", "")
+	unsetenv(t, "SLOT_UNSET")
+
+	tests := map[string]string{
+		"./$SLOT_TEAM/slots.yaml":   "./team/slots.yaml",
+		"./${SLOT_TEAM}.yaml":       "./team.yaml",
+		"./${SLOT_EMPTY}slots.yaml": "./slots.yaml",
+		"~":                         home,
+		"~/team.yaml":               filepath.Join(home, "team.yaml"),
+		"~/$SLOT_TEAM/slots.yaml":   filepath.Join(home, "team", "slots.yaml"),
+		"./~/team.yaml":             "./~/team.yaml",
+		"~team/slots.yaml":          "~team/slots.yaml",
+	}
+
+	for path, want := range tests {
+		if got, err := expand(path); err != nil || got != want {
+			t.Errorf("%s: got %q, %v, want %q", path, got, err, want)
+		}
+	}
+
+	_, err := expand("./$SLOT_UNSET/${SLOT_TEAM}/${SLOT_UNSET2}.yaml")
+	if !errors.Is(err, errUnsetVariable) || !strings.Contains(err.Error(), "SLOT_UNSET, SLOT_UNSET2") {
+		t.Errorf("got error %v, want both unset variables", err)
+	}
+}
+
+func TestOptionalIncludes(t *testing.T) {
+	unsetenv(t, "SLOT_UNSET")
+
+	tests := []struct {
+		name    string
+		include string
+		err     string
+	}{
+		{name: "unset variable", include: "./$SLOT_UNSET/slots.yaml", err: "SLOT_UNSET"},
+		{name: "missing file", include: "./missing.yaml", err: "missing.yaml"},
+		{name: "missing file under ~", include: "~/missing.yaml", err: "missing.yaml"},
+	}
+
+	for _, test := range tests {
+		store := newTestStore(t, "include:\n  - "+test.include+"\n")
+
+		if _, err := store.Load(); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want one mentioning %q", test.name, err, test.err)
+		}
+
+		store = newTestStore(t, "include:\n  - path: "+test.include+"\n    optional: true\n")
+
+		if _, err := store.Load(); err != nil {
+			t.Errorf("%s: optional include: %v", test.name, err)
+		}
+	}
+
+	// Includes under ~ resolve from the home directory.
+	store := newTestStore(t, "include:\n  - path: ~/team/slots.yaml\n    optional: true\n")
+
+	writeFiles(t, filepath.Dir(store.Path()), map[string]string{
+		"team/slots.yaml": "slots:\n  - name: shared\n    cmd: true\n",
+	})
+
+	slots, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := slots.Names(); !slices.Equal(got, []string{"shared"}) {
+		t.Errorf("got slots %q, want the slot of the file under ~", got)
+	}
+}
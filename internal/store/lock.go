@@ -0,0 +1,75 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// fileLock is an advisory lock held on a lock file of the store.
+type fileLock struct {
+	file *os.File
+}
+
+// LockDir returns the directory the lock files of slots files are kept in,
+// so that no files are created next to the slots files themselves.
+func LockDir() (string, error) {
+	slotsFile, err := DefaultSlotsFile()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(slotsFile), "cache", "locks"), nil
+}
+
+// lock acquires an exclusive advisory lock for the store, blocking until it is available.
+// The lock file is named by a hash of the store's absolute path, with symlinks resolved,
+// so that every path to the same file takes the same lock.
+func (store Store) lock() (*fileLock, error) {
+	dir, err := LockDir()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("creating lock directory %q: %w", filepath.ToSlash(dir), err)
+	}
+
+	target, err := filepath.Abs(store.Path())
+	if err != nil {
+		return nil, fmt.Errorf("resolving %q: %w", filepath.ToSlash(store.Path()), err)
+	}
+
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+
+	key := sha256.Sum256([]byte(target))
+	path := filepath.Join(dir, hex.EncodeToString(key[:8])+".lock")
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file %q: %w", filepath.ToSlash(path), err)
+	}
+
+	if err := lockFile(file); err != nil {
+		file.Close()
+
+		return nil, fmt.Errorf("locking %q: %w", filepath.ToSlash(path), err)
+	}
+
+	return &fileLock{file: file}, nil
+}
+
+// unlock releases the lock.
+func (l *fileLock) unlock() error {
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+
+		return err
+	}
+
+	return l.file.Close()
+}
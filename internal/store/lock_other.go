@@ -0,0 +1,20 @@
+//go:build !unix && !windows
+
+package store
+
+import "os"
+
+// lockFile is a no-op on platforms without file locking.
+func lockFile(*os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op on platforms without file locking.
+func unlockFile(*os.File) error {
+	return nil
+}
+
+// syncDir is a no-op on platforms without directory syncing.
+func syncDir(string) error {
+	return nil
+}
@@ -0,0 +1,60 @@
+//go:build windows
+
+package store
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+// lockfileExclusiveLock requests an exclusive lock from LockFileEx.
+const lockfileExclusiveLock = 0x2
+
+// lockFile places an exclusive lock on the first byte of the file.
+func lockFile(file *os.File) error {
+	var overlapped syscall.Overlapped
+
+	ret, _, err := procLockFileEx.Call(
+		file.Fd(),
+		lockfileExclusiveLock,
+		0,
+		1,
+		0,
+		uintptr(unsafe.Pointer(&overlapped)),
+	)
+	if ret == 0 {
+		return err
+	}
+
+	return nil
+}
+
+// unlockFile releases the lock on the file.
+func unlockFile(file *os.File) error {
+	var overlapped syscall.Overlapped
+
+	ret, _, err := procUnlockFileEx.Call(
+		file.Fd(),
+		0,
+		1,
+		0,
+		uintptr(unsafe.Pointer(&overlapped)),
+	)
+	if ret == 0 {
+		return err
+	}
+
+	return nil
+}
+
+// syncDir is a no-op, as directories cannot be synced on Windows.
+func syncDir(string) error {
+	return nil
+}
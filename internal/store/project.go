@@ -0,0 +1,78 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ProjectFile is the name of project slots files, see FindProject.
+const ProjectFile = ".slots.yaml"
+
+// FindProject walks up from dir to the root of the filesystem and returns the path of
+// the first project slots file found.
+func FindProject(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+
+		dir = parent
+	}
+}
+
+// WithProject returns the store layered below the project slots file at path.
+// Slots of the project file and its includes take precedence over those of the store.
+func (store Store) WithProject(path string) Store {
+	store.project = path
+
+	return store
+}
+
+// WithLocalProject returns the store layered below its project slots file,
+// or below a new one in the working directory if it has none.
+func (store Store) WithLocalProject() Store {
+	if store.project == "" {
+		store.project = ProjectFile
+	}
+
+	return store
+}
+
+// Project returns the path of the project slots file, or an empty string if there is none.
+func (store Store) Project() string {
+	return store.project
+}
+
+// roots returns the files the include graph starts from, in load order.
+func (store Store) roots() []Store {
+	if store.project == "" {
+		return []Store{{path: store.path}}
+	}
+
+	return []Store{{path: store.project}, {path: store.path}}
+}
+
+// traverse walks the include graph from each root in load order, visiting every file once.
+// It reports whether visit stopped the walk.
+func (store Store) traverse(visit visitFunc) (bool, error) {
+	visited := map[Store]bool{}
+
+	for _, root := range store.roots() {
+		if stop, err := root.walk(true, includeStack{}, visited, scope{}, visit); err != nil || stop {
+			return stop, err
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/idelchi/slot/internal/slot"
+)
+
+func TestFindProject(t *testing.T) {
+	repo := t.TempDir()
+
+	writeFiles(t, repo, map[string]string{
+		ProjectFile:                            "",
+		filepath.Join("nested", ProjectFile):   "",
+		filepath.Join("nested", "a", "b", "c"): "",
+	})
+
+	// A directory named like a project file is skipped.
+	if err := os.MkdirAll(filepath.Join(repo, "other", ProjectFile), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		".":                               ProjectFile,
+		"nested":                          filepath.Join("nested", ProjectFile),
+		filepath.Join("nested", "a", "b"): filepath.Join("nested", ProjectFile),
+		"other":                           ProjectFile,
+	}
+
+	for dir, want := range tests {
+		got, ok := FindProject(filepath.Join(repo, dir))
+		if !ok || got != filepath.Join(repo, want) {
+			t.Errorf("%s: got %q, %v, want %q", dir, got, ok, want)
+		}
+	}
+}
+
+func TestProjectTakesPrecedence(t *testing.T) {
+	store := newTestStore(t, "slots:\n  - name: hello\n    cmd: echo user\n  - name: user\n    cmd: true\n")
+	project := filepath.Join(t.TempDir(), ProjectFile)
+
+	if err := os.WriteFile(project, []byte("slots:\n  - name: hello\n    cmd: echo project\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store = store.WithProject(project)
+
+	slots, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := slots.Get("hello"); got == nil || got.Cmd != "echo project" || got.Source != project {
+		t.Errorf("got %+v, want hello from the project file", got)
+	}
+
+	if !slots.Exists("user") {
+		t.Error("slots of the user slots file are missing")
+	}
+
+	// Saving without a file targets the user slots file, not the project file.
+	if _, err := store.Save("", slot.Slot{Name: "mine", Cmd: "true"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Save(project, slot.Slot{Name: "build", Cmd: "go build"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "slots:\n  - name: hello\n    cmd: echo project\n  - name: build\n    cmd: go build\n"; string(data) != want {
+		t.Errorf("got project file:\n%s\nwant:\n%s", data, want)
+	}
+}
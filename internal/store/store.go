@@ -3,6 +3,7 @@ package store
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,8 +16,35 @@ import (
 )
 
 type slotsFile struct {
-	Include []string `json:"include,omitempty"`
+	Include []Include `json:"include,omitempty"`
 	Slots   slot.Slots
+
+	// data holds the raw content the file was read from, used to preserve its formatting.
+	data []byte
+	// sum is the hash of the content the file was read from, or empty if the file does not exist.
+	sum string
+	// origins are the positions of Slots in the file as it was read, or -1 for added slots,
+	// so that edited entries keep their comments. Use add and delete to keep them in sync.
+	origins []int
+}
+
+// add appends a slot to the file.
+func (file *slotsFile) add(s slot.Slot) {
+	file.Slots.Add(s)
+	file.origins = append(file.origins, -1)
+}
+
+// delete removes the slot with the given name from the file, returning true if found and deleted.
+func (file *slotsFile) delete(name string) bool {
+	i := slices.IndexFunc(file.Slots, func(s slot.Slot) bool { return s.Name == name })
+	if i == -1 {
+		return false
+	}
+
+	file.Slots = slices.Delete(file.Slots, i, i+1)
+	file.origins = slices.Delete(file.origins, i, i+1)
+
+	return true
 }
 
 type includeStack struct {
@@ -24,16 +52,20 @@ type includeStack struct {
 }
 
 // Store handles persistent storage operations for slot data.
-type Store string
+// It is a slots file, optionally layered below a project slots file, see WithProject.
+type Store struct {
+	path    string
+	project string
+}
 
 // Path returns the file path of the slot store.
 func (store Store) Path() string {
-	return string(store)
+	return store.path
 }
 
 // New creates a new Store instance from the given file path.
 func New(slotsFile string) (Store, error) {
-	store := Store(slotsFile)
+	store := Store{path: slotsFile}
 
 	dataDir := filepath.Dir(slotsFile)
 
@@ -51,7 +83,7 @@ func (store Store) Load() (slot.Slots, error) {
 		return nil, err
 	}
 
-	slots, err := store.load(true, includeStack{}, map[Store]bool{})
+	slots, err := store.load()
 	if err != nil {
 		return nil, err
 	}
@@ -59,147 +91,747 @@ func (store Store) Load() (slot.Slots, error) {
 	return slots.Unique(), nil
 }
 
-// LoadLocal reads only the slots directly defined in this store.
-func (store Store) LoadLocal() (slot.Slots, error) {
+// LoadAll reads slots like Load, but keeps definitions shadowed by an earlier slot of the same name.
+func (store Store) LoadAll() (slot.Slots, error) {
 	store, err := store.clean()
 	if err != nil {
 		return nil, err
 	}
 
-	file, err := store.read(true)
+	return store.load()
+}
+
+// Files returns the paths of the store and all files it includes, in load order.
+func (store Store) Files() ([]string, error) {
+	store, err := store.clean()
 	if err != nil {
 		return nil, err
 	}
 
-	return file.Slots, nil
+	files, err := store.files()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(files))
+
+	for i, file := range files {
+		paths[i] = file.store.Path()
+	}
+
+	return paths, nil
+}
+
+// Qualify returns the name a slot called name gets when saved to file, resolved as in Save,
+// by adding the namespace of the include leading to file unless name already has it.
+func (store Store) Qualify(file, name string) (string, error) {
+	store, err := store.clean()
+	if err != nil {
+		return "", err
+	}
+
+	target, err := store.locate(file)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(name, target.scope.prefix) {
+		return name, nil
+	}
+
+	return target.scope.prefix + name, nil
 }
 
 // Delete removes the visible slot with the given name from the file that defines it.
 func (store Store) Delete(name string) (bool, error) {
+	return store.modify(name, func(file *slotsFile, local string, _ scope) (bool, error) {
+		return file.delete(local), nil
+	})
+}
+
+// Replace overwrites the visible slot with the given name in the file that defines it,
+// keeping its position in that file.
+func (store Store) Replace(name string, replacement slot.Slot) (bool, error) {
+	return store.modify(name, func(file *slotsFile, local string, scope scope) (bool, error) {
+		existing := file.Slots.Get(local)
+		if existing == nil {
+			return false, nil
+		}
+
+		replacement, err := scope.localize(replacement, existing)
+		if err != nil {
+			return false, err
+		}
+
+		*existing = replacement
+
+		return true, nil
+	})
+}
+
+// Save adds the slot to file, replacing a slot of the same name defined there if overwrite is set.
+// An empty file selects the store itself; otherwise file is the path or name of an include in any
+// include list, or a path. Files that are not part of the include graph are created and added to
+// the store's includes, which is reported by the returned bool.
+func (store Store) Save(file string, s slot.Slot, overwrite bool) (bool, error) {
 	store, err := store.clean()
 	if err != nil {
 		return false, err
 	}
 
-	foundStore, found, err := store.find(name, true, includeStack{}, map[Store]bool{})
+	target, err := store.locate(file)
 	if err != nil {
 		return false, err
 	}
 
-	if !found {
-		return false, nil
+	if err := store.checkPin(target.store); err != nil {
+		return false, err
 	}
 
-	file, err := foundStore.read(false)
+	if err := os.MkdirAll(filepath.Dir(target.store.Path()), 0o750); err != nil {
+		return false, fmt.Errorf("creating directory for %q: %w", filepath.ToSlash(target.store.Path()), err)
+	}
+
+	_, err = os.Stat(target.store.Path())
+	created := errors.Is(err, os.ErrNotExist)
+
+	_, err = target.store.edit(true, func(file *slotsFile) (bool, error) {
+		local, err := target.scope.localize(s, nil)
+		if err != nil {
+			return false, err
+		}
+
+		existing := file.Slots.Get(local.Name)
+		if existing == nil {
+			file.add(local)
+
+			return true, nil
+		}
+
+		if !overwrite {
+			return false, fmt.Errorf("slot %q already exists in %q", s.Name, filepath.ToSlash(target.store.Path()))
+		}
+
+		if *existing, err = target.scope.localize(s, existing); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
 	if err != nil {
 		return false, err
 	}
 
-	file.Slots.Delete(name)
+	// Project files are only loaded once trusted, see Trusted, so the ones created here are trusted right away.
+	if created && target.store.Path() == store.project {
+		if _, err := record([]string{store.project}, true, func(*trusted) bool { return true }); err != nil {
+			return false, err
+		}
+	}
 
-	if err := foundStore.write(file); err != nil {
-		return false, err
+	if target.included {
+		return false, nil
 	}
 
-	return true, nil
+	return true, store.include(target.store)
 }
 
-// Save writes the slots to disk.
-func (store Store) Save(slots slot.Slots) error {
-	store, err := store.clean()
+// Insert adds the slot to file, which must be the store itself or one of its includes.
+// It fails if file already defines a slot with the same name.
+func (store Store) Insert(file string, s slot.Slot) error {
+	target, err := store.member(file)
 	if err != nil {
 		return err
 	}
 
-	file, err := store.read(true)
-	if err != nil {
+	if err := store.checkPin(target.store); err != nil {
 		return err
 	}
 
-	file.Slots = slots
+	_, err = target.store.edit(true, func(file *slotsFile) (bool, error) {
+		local, err := target.scope.localize(s, nil)
+		if err != nil {
+			return false, err
+		}
+
+		if file.Slots.Exists(local.Name) {
+			return false, fmt.Errorf("slot %q already exists in %q", s.Name, filepath.ToSlash(target.store.Path()))
+		}
+
+		file.add(local)
+
+		return true, nil
+	})
 
-	return store.write(file)
+	return err
 }
 
-// load reads the store's slots and recursively includes its dependencies.
-func (store Store) load(allowMissing bool, stack includeStack, visited map[Store]bool) (slot.Slots, error) {
-	if slices.Contains(stack.stores, store) {
-		return nil, fmt.Errorf("recursive include: %s", stack.formatCycle(store))
+// Move moves the visible slot with the given name into file, which must be the store itself
+// or one of its includes, and returns its new name, which changes if file has another namespace.
+// The move is refused if another definition would take precedence over the moved slot afterwards,
+// or if its new name is already used. Both files are locked for the whole move and the slot is added
+// to file before it is removed from its current file. If removing it fails, file is restored.
+func (store Store) Move(name, file string) (string, error) {
+	store, err := store.clean()
+	if err != nil {
+		return "", err
 	}
 
-	if visited[store] {
-		return nil, nil
+	source, local, target, unlock, ok, err := store.lockMove(name, file)
+	if err != nil || !ok {
+		return "", err
 	}
 
-	visited[store] = true
+	defer unlock()
 
-	file, err := store.read(allowMissing)
+	for _, file := range []Store{source.store, target.store} {
+		if err := store.checkPin(file); err != nil {
+			return "", err
+		}
+	}
+
+	newName := target.scope.prefix + local
+
+	if err := store.checkPrecedence(name, newName, target); err != nil {
+		return "", err
+	}
+
+	sourceFile, err := source.store.read(false)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+
+	targetFile, err := target.store.read(true)
+	if err != nil {
+		return "", err
+	}
+
+	if targetFile.Slots.Exists(local) {
+		return "", fmt.Errorf("slot %q already exists in %q", newName, filepath.ToSlash(target.store.Path()))
 	}
 
-	stack.stores = append(stack.stores, store)
+	// The original target, to restore it if the source cannot be written.
+	original, err := os.ReadFile(target.store.Path())
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("reading slots file %q: %w", filepath.ToSlash(target.store.Path()), err)
+	}
 
-	slots := append(slot.Slots{}, file.Slots...)
+	created := err != nil
 
-	for _, include := range file.Include {
-		includeStore, err := store.resolveInclude(include)
+	if _, err := target.store.update(true, func(file *slotsFile) (bool, error) {
+		file.add(*sourceFile.Slots.Get(local))
+
+		return true, nil
+	}); err != nil {
+		return "", err
+	}
+
+	if _, err := source.store.update(false, func(file *slotsFile) (bool, error) {
+		return file.delete(local), nil
+	}); err != nil {
+		return "", errors.Join(err, target.store.restore(original, created))
+	}
+
+	return newName, nil
+}
+
+// lockMove locks the file that defines the visible slot with name and the file it is moved to,
+// in the order of their paths, so that concurrent moves cannot deadlock. The slot is looked up again
+// once the locks are held, as another process may have moved it in the meantime.
+func (store Store) lockMove(name, file string) (located, string, located, func(), bool, error) {
+	target, err := store.member(file)
+	if err != nil {
+		return located{}, "", located{}, nil, false, err
+	}
+
+	for {
+		source, local, ok, err := store.find(name)
+		if err != nil || !ok {
+			return located{}, "", located{}, nil, ok, err
+		}
+
+		if target.store == source.store {
+			return located{}, "", located{}, nil, false, fmt.Errorf(
+				"slot %q is already defined in %q", name, filepath.ToSlash(target.store.Path()),
+			)
+		}
+
+		unlock, err := lockAll(source.store, target.store)
 		if err != nil {
+			return located{}, "", located{}, nil, false, err
+		}
+
+		locked, lockedLocal, ok, err := store.find(name)
+
+		switch {
+		case err != nil || !ok:
+			unlock()
+
+			return located{}, "", located{}, nil, ok, err
+		case locked.store != source.store || lockedLocal != local:
+			unlock()
+
+			continue
+		}
+
+		return source, local, target, unlock, true, nil
+	}
+}
+
+// lockAll checks that the stores are writable and locks them in the order of their paths.
+// The returned function releases the locks.
+func lockAll(stores ...Store) (func(), error) {
+	stores = slices.Clone(stores)
+
+	slices.SortFunc(stores, func(a, b Store) int { return strings.Compare(a.Path(), b.Path()) })
+
+	var locks []*fileLock
+
+	unlock := func() {
+		for _, lock := range slices.Backward(locks) {
+			lock.unlock()
+		}
+	}
+
+	for _, store := range stores {
+		if err := store.writable(); err != nil {
+			unlock()
+
 			return nil, err
 		}
 
-		includedSlots, err := includeStore.load(false, stack, visited)
+		lock, err := store.lock()
 		if err != nil {
+			unlock()
+
 			return nil, err
 		}
 
-		slots = append(slots, includedSlots...)
+		locks = append(locks, lock)
+	}
+
+	return unlock, nil
+}
+
+// restore writes back the original content of the store, or removes it if it was created.
+// The caller holds the lock of the file.
+func (store Store) restore(original []byte, created bool) error {
+	current, err := store.read(true)
+	if err != nil {
+		return err
+	}
+
+	if created {
+		err = os.Remove(store.Path())
+	} else {
+		err = store.writeAtomic(original)
+	}
+
+	if err != nil {
+		return fmt.Errorf("restoring %q: %w", filepath.ToSlash(store.Path()), err)
+	}
+
+	return store.retrust(current.sum)
+}
+
+// checkPrecedence fails if, after moving the visible slot name to target as newName,
+// another definition of newName would take precedence over it or be shadowed by it.
+func (store Store) checkPrecedence(name, newName string, target located) error {
+	files, err := store.files()
+	if err != nil {
+		return err
+	}
+
+	position := slices.IndexFunc(files, func(file located) bool { return file.store == target.store })
+
+	all, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	skipped := false
+
+	for _, other := range all {
+		if other.Name == name && !skipped {
+			// The visible definition is the one being moved.
+			skipped = true
+
+			continue
+		}
+
+		if other.Name != newName {
+			continue
+		}
+
+		index := slices.IndexFunc(files, func(file located) bool { return file.store.Path() == other.Source })
+
+		if newName != name || index <= position {
+			return fmt.Errorf(
+				"slot %q is also defined in %q, which would conflict with the moved slot",
+				newName,
+				filepath.ToSlash(other.Source),
+			)
+		}
 	}
 
-	return slots, nil
+	return nil
 }
 
-// find returns the store that defines the visible slot with name.
-func (store Store) find(
+// modify applies fn to the file that defines the visible slot with name, and writes the file
+// if fn reports a change. fn receives the name of the slot within the file.
+func (store Store) modify(
 	name string,
+	fn func(file *slotsFile, local string, scope scope) (bool, error),
+) (bool, error) {
+	store, err := store.clean()
+	if err != nil {
+		return false, err
+	}
+
+	found, local, lock, ok, err := store.lockSlot(name)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	defer lock.unlock()
+
+	if err := store.checkPin(found.store); err != nil {
+		return false, err
+	}
+
+	return found.store.update(false, func(file *slotsFile) (bool, error) {
+		return fn(file, local, found.scope)
+	})
+}
+
+// lockSlot locks the file that defines the visible slot with name and returns it with the slot's name
+// within the file. The slot is looked up again once the lock is held, as another process may have moved
+// it in the meantime.
+func (store Store) lockSlot(name string) (located, string, *fileLock, bool, error) {
+	for {
+		found, local, ok, err := store.find(name)
+		if err != nil || !ok {
+			return located{}, "", nil, ok, err
+		}
+
+		if err := found.store.writable(); err != nil {
+			return located{}, "", nil, false, err
+		}
+
+		lock, err := found.store.lock()
+		if err != nil {
+			return located{}, "", nil, false, err
+		}
+
+		locked, lockedLocal, ok, err := store.find(name)
+
+		switch {
+		case err != nil || !ok:
+			lock.unlock()
+
+			return located{}, "", nil, ok, err
+		case locked.store != found.store || lockedLocal != local:
+			lock.unlock()
+
+			continue
+		}
+
+		return found, local, lock, true, nil
+	}
+}
+
+// edit locks the file, applies fn to it and writes the file if fn reports a change.
+func (store Store) edit(allowMissing bool, fn func(file *slotsFile) (bool, error)) (bool, error) {
+	if err := store.writable(); err != nil {
+		return false, err
+	}
+
+	lock, err := store.lock()
+	if err != nil {
+		return false, err
+	}
+
+	defer lock.unlock()
+
+	return store.update(allowMissing, fn)
+}
+
+// update applies fn to the file and writes the file if fn reports a change.
+// The caller holds the lock of the file.
+func (store Store) update(allowMissing bool, fn func(file *slotsFile) (bool, error)) (bool, error) {
+	file, err := store.read(allowMissing)
+	if err != nil {
+		return false, err
+	}
+
+	changed, err := fn(&file)
+	if err != nil || !changed {
+		return false, err
+	}
+
+	if err := store.write(file); err != nil {
+		return false, err
+	}
+
+	return true, store.retrust(file.sum)
+}
+
+// include appends target to the includes of the store, relative to the store's directory if possible.
+func (store Store) include(target Store) error {
+	entry := target.Path()
+
+	if relative, err := filepath.Rel(filepath.Dir(store.Path()), entry); err == nil {
+		entry = "./" + relative
+	}
+
+	if err := store.writable(); err != nil {
+		return err
+	}
+
+	lock, err := store.lock()
+	if err != nil {
+		return err
+	}
+
+	defer lock.unlock()
+
+	file, err := store.read(true)
+	if err != nil {
+		return err
+	}
+
+	file.Include = append(file.Include, Include{Path: filepath.ToSlash(entry)})
+
+	if err := store.write(file); err != nil {
+		return err
+	}
+
+	return store.retrust(file.sum)
+}
+
+// located is a file of the include graph with the scope it was first reached through.
+type located struct {
+	store    Store
+	scope    scope
+	included bool
+}
+
+// locate returns the file for file, which is the path or name of an include in the include list
+// of any file in the include graph, or a path. An empty file resolves to the store itself,
+// not to its project slots file.
+func (store Store) locate(file string) (located, error) {
+	files, err := store.files()
+	if err != nil {
+		return located{}, err
+	}
+
+	if file == "" {
+		return files[slices.IndexFunc(files, func(file located) bool { return file.store.Path() == store.Path() })], nil
+	}
+
+	target, err := store.resolve(file)
+	if err != nil {
+		return located{}, err
+	}
+
+	for _, candidate := range files {
+		if candidate.store == target.store {
+			return candidate, nil
+		}
+	}
+
+	return target, nil
+}
+
+// resolve returns the file for file, see locate. Files declared by an include are reported as included,
+// even if they are optional and do not exist yet.
+func (store Store) resolve(file string) (located, error) {
+	path, err := Store{path: file}.clean()
+	if err != nil {
+		return located{}, err
+	}
+
+	found := located{store: path}
+
+	_, err = store.traverse(func(store Store, slotsFile slotsFile, scope scope) (bool, error) {
+		for _, include := range slotsFile.Include {
+			named := include.Path == file || include.Name == file
+			if !named && !include.Optional {
+				continue
+			}
+
+			// Resolve missing optional includes to their path, so they can be created.
+			optional := include.Optional
+			include.Optional = false
+
+			resolved, err := store.resolveInclude(include)
+
+			switch {
+			case !named && (err != nil || len(resolved) != 1 || resolved[0] != path):
+				continue
+			case err != nil:
+				return false, err
+			case len(resolved) != 1:
+				return false, fmt.Errorf("include %q matches %d files, not a single file", file, len(resolved))
+			}
+
+			include.Optional = optional
+			found = located{store: resolved[0], scope: scope.include(include), included: true}
+
+			return true, nil
+		}
+
+		return false, nil
+	})
+
+	return found, err
+}
+
+// member returns the file for file, which must be the store itself or one of its includes.
+// file is resolved as in Save.
+func (store Store) member(file string) (located, error) {
+	store, err := store.clean()
+	if err != nil {
+		return located{}, err
+	}
+
+	target, err := store.locate(file)
+	if err != nil {
+		return located{}, err
+	}
+
+	if !target.included {
+		return located{}, fmt.Errorf(
+			"%q is neither %q nor one of its includes",
+			filepath.ToSlash(target.store.Path()),
+			filepath.ToSlash(store.Path()),
+		)
+	}
+
+	return target, nil
+}
+
+// visitFunc is called for every file in the include graph with the scope it is reached through.
+// Returning true stops the walk.
+type visitFunc func(store Store, file slotsFile, scope scope) (bool, error)
+
+// walk visits the store and its includes depth-first, in load order, skipping files already visited.
+// It reports whether visit stopped the walk.
+func (store Store) walk(
 	allowMissing bool,
 	stack includeStack,
 	visited map[Store]bool,
-) (Store, bool, error) {
+	scope scope,
+	visit visitFunc,
+) (bool, error) {
 	if slices.Contains(stack.stores, store) {
-		return "", false, fmt.Errorf("recursive include: %s", stack.formatCycle(store))
+		return false, fmt.Errorf("recursive include: %s", stack.formatCycle(store))
 	}
 
 	if visited[store] {
-		return "", false, nil
+		return false, nil
 	}
 
 	visited[store] = true
 
 	file, err := store.read(allowMissing)
 	if err != nil {
-		return "", false, err
+		return false, err
 	}
 
-	if file.Slots.Exists(name) {
-		return store, true, nil
+	if stop, err := visit(store, file, scope); err != nil || stop {
+		return stop, err
 	}
 
-	stack.stores = append(stack.stores, store)
+	// The stack holds only the ancestors of the included files, so a file reached again through
+	// another path, such as two globs matching it, is no cycle. Clipping it keeps siblings from sharing it.
+	stack.stores = append(slices.Clip(stack.stores), store)
+
+	included := map[Store]bool{}
 
 	for _, include := range file.Include {
-		includeStore, err := store.resolveInclude(include)
+		includeStores, err := store.resolveInclude(include)
 		if err != nil {
-			return "", false, err
+			return false, err
 		}
 
-		foundStore, found, err := includeStore.find(name, false, stack, visited)
-		if err != nil || found {
-			return foundStore, found, err
+		for _, includeStore := range includeStores {
+			if include.SHA256 != "" {
+				if err := includeStore.verifyPin(include); err != nil {
+					return false, err
+				}
+			}
+
+			if included[includeStore] {
+				continue
+			}
+
+			included[includeStore] = true
+
+			if stop, err := includeStore.walk(false, stack, visited, scope.include(include), visit); err != nil || stop {
+				return stop, err
+			}
 		}
 	}
 
-	return "", false, nil
+	return false, nil
+}
+
+// files returns the store and all files it includes, in load order.
+func (store Store) files() ([]located, error) {
+	var files []located
+
+	_, err := store.traverse(func(store Store, _ slotsFile, scope scope) (bool, error) {
+		files = append(files, located{store: store, scope: scope, included: true})
+
+		return false, nil
+	})
+
+	return files, err
+}
+
+// load reads the store's slots and recursively includes its dependencies.
+// Slots defined again after an earlier slot of the same name are marked as shadowed.
+func (store Store) load() (slot.Slots, error) {
+	var slots slot.Slots
+
+	seen := map[string]bool{}
+
+	_, err := store.traverse(func(store Store, file slotsFile, scope scope) (bool, error) {
+		for _, s := range file.Slots {
+			s = scope.qualify(s)
+			s.Source = store.Path()
+			s.Shadowed = seen[s.Name]
+			seen[s.Name] = true
+			slots = append(slots, s)
+		}
+
+		return false, nil
+	})
+
+	return slots, err
+}
+
+// find returns the file that defines the visible slot with name, and the slot's name within that file.
+func (store Store) find(name string) (located, string, bool, error) {
+	var (
+		found located
+		local string
+	)
+
+	ok, err := store.traverse(func(store Store, file slotsFile, scope scope) (bool, error) {
+		for _, s := range file.Slots {
+			if scope.prefix+s.Name == name {
+				found, local = located{store: store, scope: scope, included: true}, s.Name
+
+				return true, nil
+			}
+		}
+
+		return false, nil
+	})
+
+	return found, local, ok, err
 }
 
 // read reads one slots file from disk.
@@ -215,6 +847,8 @@ func (store Store) read(allowMissing bool) (slotsFile, error) {
 		return file, fmt.Errorf("reading slots file %q: %w", filepath.ToSlash(store.Path()), err)
 	}
 
+	file.sum = hash(data)
+
 	if len(bytes.TrimSpace(data)) == 0 {
 		return file, nil
 	}
@@ -223,11 +857,40 @@ func (store Store) read(allowMissing bool) (slotsFile, error) {
 		return file, fmt.Errorf("unmarshalling slots file %q: %w", filepath.ToSlash(store.Path()), err)
 	}
 
+	file.data = data
+
+	for i := range file.Slots {
+		file.origins = append(file.origins, i)
+	}
+
 	return file, nil
 }
 
 // write writes one slots file to disk.
+// Existing files are edited in place, so comments and the formatting of unchanged slots are kept.
+// Files that cannot be edited in place are refused rather than rewritten without their comments.
 func (store Store) write(file slotsFile) error {
+	if len(file.data) > 0 {
+		data, ok, err := splice(file.data, file)
+		if err != nil {
+			return fmt.Errorf("editing slots file %q: %w", filepath.ToSlash(store.Path()), err)
+		}
+
+		if !ok {
+			return fmt.Errorf(
+				"editing slots file %q: cannot edit its layout in place without losing comments, "+
+					"write slots and include as block-style sequences in a single document",
+				filepath.ToSlash(store.Path()),
+			)
+		}
+
+		if err := store.writeAtomic(data); err != nil {
+			return fmt.Errorf("writing file %q: %w", filepath.ToSlash(store.Path()), err)
+		}
+
+		return nil
+	}
+
 	data, err := yaml.MarshalWithOptions(
 		file,
 		yaml.IndentSequence(true),
@@ -239,34 +902,182 @@ func (store Store) write(file slotsFile) error {
 
 	data = bytes.TrimRight(data, "\n")
 
-	if err := os.WriteFile(store.Path(), data, 0o600); err != nil {
+	if err := store.writeAtomic(data); err != nil {
 		return fmt.Errorf("writing file %q: %w", filepath.ToSlash(store.Path()), err)
 	}
 
 	return nil
 }
 
-// resolveInclude returns the store for an include declared by this store.
-func (store Store) resolveInclude(includePath string) (Store, error) {
+// writeAtomic writes data to a temporary file in the store's directory, syncs it,
+// and renames it over the store so readers never observe a partial file.
+// A symlinked store, as kept in dotfiles repositories, is written through to its target,
+// and the mode of an existing file is kept.
+func (store Store) writeAtomic(data []byte) error {
+	path := store.Path()
+	mode := os.FileMode(0o600)
+
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+	}
+
+	dir, base := filepath.Split(path)
+
+	tmp, err := os.CreateTemp(dir, "."+base+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// resolveInclude returns the stores for an include declared by this store, in load order.
+// Environment variables and a leading ~ in the path are expanded.
+// A glob pattern matches any number of files and a directory includes its YAML files; both are
+// sorted by path and never include the declaring file itself.
+// Optional includes resolve to no stores if the file is missing or the path uses an unset variable.
+// Includes from git repositories resolve to their cached checkout, see resolveGit.
+func (store Store) resolveInclude(include Include) ([]Store, error) {
+	if include.Git != "" {
+		return store.resolveGit(include)
+	}
+
+	if include.Path == "" {
+		return nil, fmt.Errorf("empty include in %q", filepath.ToSlash(store.Path()))
+	}
+
+	includePath, err := expand(include.Path)
+	if err != nil {
+		if include.Optional && errors.Is(err, errUnsetVariable) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("include %q in %q: %w", include.Path, filepath.ToSlash(store.Path()), err)
+	}
+
 	if includePath == "" {
-		return "", fmt.Errorf("empty include in %q", filepath.ToSlash(store.Path()))
+		return nil, fmt.Errorf("include %q in %q expands to an empty path", include.Path, filepath.ToSlash(store.Path()))
 	}
 
 	if !filepath.IsAbs(includePath) {
 		includePath = filepath.Join(filepath.Dir(store.Path()), includePath)
 	}
 
-	return Store(includePath).clean()
+	var (
+		paths []string
+		glob  bool
+	)
+
+	if info, err := os.Stat(includePath); err == nil && info.IsDir() {
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(includePath, pattern))
+			if err != nil {
+				return nil, err
+			}
+
+			paths = append(paths, matches...)
+		}
+	} else if isGlob(includePath) {
+		matches, err := filepath.Glob(includePath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q in %q: %w", includePath, filepath.ToSlash(store.Path()), err)
+		}
+
+		paths, glob = matches, true
+	} else {
+		if _, err := os.Stat(includePath); include.Optional && errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		included, err := Store{path: includePath}.clean()
+
+		return []Store{included}, err
+	}
+
+	slices.Sort(paths)
+
+	stores := make([]Store, 0, len(paths))
+	matched := false
+
+	for _, path := range paths {
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+
+		matched = true
+
+		included, err := Store{path: path}.clean()
+		if err != nil {
+			return nil, err
+		}
+
+		if included != store {
+			stores = append(stores, included)
+		}
+	}
+
+	if glob && !matched && !include.Optional {
+		return nil, fmt.Errorf("include %q in %q matches no files", include.Path, filepath.ToSlash(store.Path()))
+	}
+
+	return stores, nil
+}
+
+// isGlob reports whether path contains glob metacharacters.
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
 }
 
-// clean returns the absolute, cleaned store path.
+// clean returns the store with absolute, cleaned paths.
 func (store Store) clean() (Store, error) {
 	absolute, err := filepath.Abs(store.Path())
 	if err != nil {
-		return "", fmt.Errorf("resolving slots file path %q: %w", store.Path(), err)
+		return Store{}, fmt.Errorf("resolving slots file path %q: %w", store.Path(), err)
 	}
 
-	return Store(filepath.Clean(absolute)), nil
+	store.path = filepath.Clean(absolute)
+
+	if store.project != "" {
+		if absolute, err = filepath.Abs(store.project); err != nil {
+			return Store{}, fmt.Errorf("resolving project slots file path %q: %w", store.project, err)
+		}
+
+		store.project = filepath.Clean(absolute)
+	}
+
+	return store, nil
 }
 
 // formatCycle formats the recursive include path for error messages.
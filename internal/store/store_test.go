@@ -0,0 +1,367 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// newTestStore returns a store in a temporary directory, with the home directory moved there as well,
+// so the git cache and trust database of the user are never touched.
+func newTestStore(t *testing.T, content string) Store {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	t.Setenv("HOME", dir)
+	t.Setenv("USERPROFILE", dir)
+
+	path := filepath.Join(dir, "slots.yaml")
+
+	if content != "" {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+// TestSaveProcess saves the slots named in $SLOT_TEST_SLOTS to $SLOT_TEST_STORE.
+// It only runs as a child process of TestConcurrentSaves.
+func TestSaveProcess(t *testing.T) {
+	path := os.Getenv("SLOT_TEST_STORE")
+	if path == "" {
+		t.Skip("only runs as a child process of TestConcurrentSaves")
+	}
+
+	for name := range strings.FieldsSeq(os.Getenv("SLOT_TEST_SLOTS")) {
+		if _, err := (Store{path: path}).Save("", slot.Slot{Name: name, Cmd: "echo " + name}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	const (
+		goroutines = 16
+		processes  = 4
+		perProcess = 8
+	)
+
+	store := newTestStore(t, "slots:\n  - name: shared\n    cmd: echo 0\n")
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, goroutines*2+processes)
+		want = []string{"shared"}
+	)
+
+	for i := range goroutines {
+		name := "goroutine-" + strconv.Itoa(i)
+		want = append(want, name)
+
+		wg.Go(func() {
+			_, err := store.Save("", slot.Slot{Name: name, Cmd: "echo " + name}, false)
+			errs <- err
+		})
+
+		wg.Go(func() {
+			_, err := store.Replace("shared", slot.Slot{Name: "shared", Cmd: "echo " + strconv.Itoa(i)})
+			errs <- err
+		})
+	}
+
+	for i := range processes {
+		var names []string
+
+		for j := range perProcess {
+			names = append(names, fmt.Sprintf("process-%d-%d", i, j))
+		}
+
+		want = append(want, names...)
+
+		wg.Go(func() {
+			//nolint:gosec,noctx	// The test binary runs itself.
+			cmd := exec.Command(os.Args[0], "-test.run=^TestSaveProcess$")
+			cmd.Env = append(os.Environ(), "SLOT_TEST_STORE="+store.Path(), "SLOT_TEST_SLOTS="+strings.Join(names, " "))
+
+			if output, err := cmd.CombinedOutput(); err != nil {
+				errs <- fmt.Errorf("%w: %s", err, output)
+
+				return
+			}
+
+			errs <- nil
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	slots, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range want {
+		if !slots.Exists(name) {
+			t.Errorf("slot %q was lost", name)
+		}
+	}
+
+	if len(slots) != len(want) {
+		t.Errorf("got %d slots, want %d", len(slots), len(want))
+	}
+}
+
+func TestSaveRefusesExistingSlot(t *testing.T) {
+	store := newTestStore(t, "slots:\n  - name: hello\n    cmd: echo hello\n")
+
+	if _, err := store.Save("", slot.Slot{Name: "hello", Cmd: "echo other"}, false); err == nil {
+		t.Fatal("saving an existing slot without overwrite succeeded")
+	}
+
+	if _, err := store.Save("", slot.Slot{Name: "hello", Cmd: "echo other"}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	slots, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := slots.Get("hello").Cmd; got != "echo other" {
+		t.Errorf("got cmd %q, want %q", got, "echo other")
+	}
+}
+
+func TestWriteAtomicLeavesNoTemporaryFiles(t *testing.T) {
+	store := newTestStore(t, "")
+
+	for i := range 5 {
+		if _, err := store.Save("", slot.Slot{Name: "slot-" + strconv.Itoa(i), Cmd: "true"}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(store.Path()), "*.tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matches) > 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestLocksLeaveNoFilesNextToSlotsFiles(t *testing.T) {
+	store := newTestStore(t, "")
+	project := filepath.Join(t.TempDir(), ".slots.yaml")
+
+	for _, file := range []string{"", project} {
+		if _, err := store.Save(file, slot.Slot{Name: "slot", Cmd: "true"}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, file := range []string{store.Path(), project} {
+		entries, err := os.ReadDir(filepath.Dir(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, entry := range entries {
+			if strings.HasSuffix(entry.Name(), ".lock") {
+				t.Errorf("lock file %q left next to %q", entry.Name(), file)
+			}
+		}
+	}
+}
+
+func TestFailedWriteKeepsOriginal(t *testing.T) {
+	const original = "# my slots\nslots:\n  - name: hello\n    cmd: echo hello\n"
+
+	store := newTestStore(t, original)
+
+	// Functions cannot be marshalled, so writing the file fails.
+	broken := slot.Slot{Name: "broken", Cmd: "true", Vars: map[string]any{"fn": func() {}}}
+
+	if _, err := store.Save("", broken, false); err == nil {
+		t.Fatal("saving an unmarshallable slot succeeded")
+	}
+
+	data, err := os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != original {
+		t.Errorf("file changed after a failed write:\n%s", data)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(store.Path()), "*.tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matches) > 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestWriteAtomicKeepsSymlinkAndMode(t *testing.T) {
+	store := newTestStore(t, "")
+
+	target := filepath.Join(t.TempDir(), "dotfiles-slots.yaml")
+
+	if err := os.WriteFile(target, []byte("slots:\n  - name: hello\n    cmd: echo hello\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(target, store.Path()); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	if _, err := store.Save("", slot.Slot{Name: "other", Cmd: "true"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Error("symlink was replaced by a regular file")
+	}
+
+	info, err = os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("got mode %v, want %v", info.Mode().Perm(), os.FileMode(0o640))
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), "name: other") {
+		t.Errorf("slot was not written to the symlink target:\n%s", data)
+	}
+}
+
+func TestConcurrentMoves(t *testing.T) {
+	const (
+		slots = 8
+		moves = 5
+	)
+
+	store := newTestStore(t, "include:\n  - ./team.yaml\n")
+	team := filepath.Join(filepath.Dir(store.Path()), "team.yaml")
+
+	if err := os.WriteFile(team, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range slots {
+		if _, err := store.Save("", slot.Slot{Name: "slot" + strconv.Itoa(i), Cmd: "true"}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, slots*moves*2)
+	)
+
+	// Every slot moves back and forth between the files, while the others move as well.
+	for i := range slots {
+		wg.Go(func() {
+			for range moves {
+				for _, file := range []string{team, store.Path()} {
+					if _, err := store.Move("slot"+strconv.Itoa(i), file); err != nil {
+						errs <- err
+					}
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	all, err := store.LoadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(all) != slots {
+		t.Errorf("got %d slots after moving them, want %d: %v", len(all), slots, all.Names())
+	}
+
+	for i := range slots {
+		if s := all.Get("slot" + strconv.Itoa(i)); s == nil || s.Source != store.Path() {
+			t.Errorf("slot%d was lost or left behind: %+v", i, s)
+		}
+	}
+}
+
+func TestRestore(t *testing.T) {
+	store := newTestStore(t, "slots:\n  - name: kept\n    cmd: true\n")
+	original, err := os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Insert(store.Path(), slot.Slot{Name: "added", Cmd: "true"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.restore(original, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(store.Path()); string(data) != string(original) {
+		t.Errorf("got %q after restoring, want %q", data, original)
+	}
+
+	created := filepath.Join(filepath.Dir(store.Path()), "created.yaml")
+	if err := os.WriteFile(created, []byte("slots: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (Store{path: created}).restore(nil, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Errorf("created file was not removed: %v", err)
+	}
+}
@@ -0,0 +1,343 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/idelchi/slot/internal/diff"
+)
+
+// trusted is a reviewed version of a slots file.
+type trusted struct {
+	// Path is the absolute path of the file.
+	Path string `json:"path"`
+	// SHA256 is the hex-encoded hash of the reviewed content.
+	SHA256 string `json:"sha256"`
+	// Content is the reviewed content, used to show what changed since.
+	Content string `json:"content"`
+	// Auto marks content recorded by slot itself, such as for a project file it created,
+	// rather than reviewed with 'slot trust'.
+	Auto bool `json:"auto,omitempty"`
+}
+
+// trustFile is the local database of reviewed slots files.
+type trustFile struct {
+	Files []trusted `json:"files"`
+}
+
+// Change is a slots file that changed since it was trusted, or that was never trusted.
+type Change struct {
+	// Path is the file that changed.
+	Path string
+	// Diff shows the changes since the trusted version.
+	Diff string
+	// New reports a file that was never trusted, which has no Diff.
+	New bool
+}
+
+// TrustFile returns the path of the trust database.
+func TrustFile() (string, error) {
+	slotsFile, err := DefaultSlotsFile()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(slotsFile), "trust.yaml"), nil
+}
+
+// Trust records the current content of files as reviewed and returns the files that were not trusted
+// in this version before. files are resolved as in Save; without files, every file of the include graph
+// except the store itself is trusted.
+func (store Store) Trust(files ...string) ([]string, error) {
+	store, err := store.clean()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+
+	if len(files) == 0 {
+		if paths, err = store.Files(); err != nil {
+			return nil, err
+		}
+
+		// The slots file itself is the user's own.
+		paths = slices.DeleteFunc(paths, func(path string) bool { return path == store.Path() })
+	}
+
+	for _, file := range files {
+		target, err := store.member(file)
+		if err != nil {
+			return nil, err
+		}
+
+		paths = append(paths, target.store.Path())
+	}
+
+	return record(paths, false, func(*trusted) bool { return true })
+}
+
+// Trusted reports whether the current content of the file at path is trusted.
+func Trusted(path string) (bool, error) {
+	database, err := TrustFile()
+	if err != nil {
+		return false, err
+	}
+
+	trust, err := readTrust(database)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("reading slots file %q: %w", filepath.ToSlash(path), err)
+	}
+
+	i := slices.IndexFunc(trust.Files, func(file trusted) bool { return file.Path == path })
+
+	return i != -1 && trust.Files[i].SHA256 == hash(data), nil
+}
+
+// retrust records the content slot wrote to the store as reviewed, so changes made through slot itself
+// are not reported. previous is the hash of the content it replaced, or empty if slot created the file.
+// The content is only recorded if the replaced content was trusted, or the file is new: a file changed
+// since it was trusted stays untrusted, as recording it would approve changes nobody reviewed,
+// and every command keeps warning about it.
+func (store Store) retrust(previous string) error {
+	path, err := TrustFile()
+	if err != nil {
+		return nil //nolint:nilerr	// Without a trust database nothing is trusted.
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	_, err = record([]string{store.Path()}, true, func(entry *trusted) bool {
+		if entry == nil {
+			return previous == ""
+		}
+
+		return entry.SHA256 == previous
+	})
+
+	return err
+}
+
+// record records the current content of paths in the trust database and returns the paths whose
+// entry changed. Paths are only recorded if keep accepts their current entry, which is nil if there is none.
+// With auto, the content is recorded by slot itself: new entries are marked as such, while existing ones
+// keep their mark. Otherwise the content was reviewed, and the mark is cleared.
+func record(paths []string, auto bool, keep func(entry *trusted) bool) ([]string, error) {
+	path, err := TrustFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("creating directory for %q: %w", filepath.ToSlash(path), err)
+	}
+
+	database := Store{path: path}
+
+	lock, err := database.lock()
+	if err != nil {
+		return nil, err
+	}
+
+	defer lock.unlock()
+
+	trust, err := readTrust(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []string
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("reading slots file %q: %w", filepath.ToSlash(path), err)
+		}
+
+		entry := trusted{Path: path, SHA256: hash(data), Content: string(data), Auto: auto}
+
+		i := slices.IndexFunc(trust.Files, func(file trusted) bool { return file.Path == path })
+
+		var current *trusted
+		if i != -1 {
+			current = &trust.Files[i]
+			entry.Auto = auto && current.Auto
+		}
+
+		switch {
+		case !keep(current):
+			continue
+		case i == -1:
+			trust.Files = append(trust.Files, entry)
+		case trust.Files[i].SHA256 != entry.SHA256 || trust.Files[i].Auto != entry.Auto:
+			trust.Files[i] = entry
+		default:
+			continue
+		}
+
+		updated = append(updated, path)
+	}
+
+	if len(updated) == 0 {
+		return nil, nil
+	}
+
+	data, err := yaml.MarshalWithOptions(trust, yaml.IndentSequence(true), yaml.UseLiteralStyleIfMultiline(true))
+	if err != nil {
+		return nil, fmt.Errorf("marshalling trust database: %w", err)
+	}
+
+	return updated, database.writeAtomic(data)
+}
+
+// Verify returns the files of the include graph that changed since they were trusted.
+// Once any file is trusted with 'slot trust', the files that were never trusted are returned as well,
+// except for the store itself, which is the user's own. Content recorded by slot itself, such as for
+// a project file it created, does not count, so that saving a project slot does not opt into reviews.
+func (store Store) Verify() ([]Change, error) {
+	store, err := store.clean()
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := TrustFile()
+	if err != nil {
+		return nil, err
+	}
+
+	trust, err := readTrust(path)
+	if err != nil || !slices.ContainsFunc(trust.Files, func(file trusted) bool { return !file.Auto }) {
+		return nil, err
+	}
+
+	var changes []Change
+
+	own := store.Path()
+
+	_, err = store.traverse(func(store Store, _ slotsFile, _ scope) (bool, error) {
+		data, err := os.ReadFile(store.Path())
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+
+		if err != nil {
+			return false, fmt.Errorf("reading slots file %q: %w", filepath.ToSlash(store.Path()), err)
+		}
+
+		i := slices.IndexFunc(trust.Files, func(file trusted) bool { return file.Path == store.Path() })
+		if i == -1 {
+			if store.Path() != own {
+				changes = append(changes, Change{Path: store.Path(), New: true})
+			}
+
+			return false, nil
+		}
+
+		if trust.Files[i].SHA256 == hash(data) {
+			return false, nil
+		}
+
+		changes = append(changes, Change{
+			Path: store.Path(),
+			Diff: diff.Lines(trust.Files[i].Content, string(data)),
+		})
+
+		return false, nil
+	})
+
+	return changes, err
+}
+
+// verifyPin returns an error if the content of the store does not match the sha256 pin of include.
+func (store Store) verifyPin(include Include) error {
+	data, err := os.ReadFile(store.Path())
+	if err != nil {
+		return fmt.Errorf("reading slots file %q: %w", filepath.ToSlash(store.Path()), err)
+	}
+
+	if actual := hash(data); !strings.EqualFold(actual, include.SHA256) {
+		return fmt.Errorf(
+			"included file %q does not match its sha256 pin: expected %s, got %s",
+			filepath.ToSlash(store.Path()),
+			include.SHA256,
+			actual,
+		)
+	}
+
+	return nil
+}
+
+// checkPin returns an error if an include pins the content of file with its sha256 hash,
+// as writing to file would break the pin.
+func (store Store) checkPin(file Store) error {
+	_, err := store.traverse(func(from Store, slotsFile slotsFile, _ scope) (bool, error) {
+		for _, include := range slotsFile.Include {
+			if include.SHA256 == "" {
+				continue
+			}
+
+			resolved, err := from.resolveInclude(include)
+			if err != nil {
+				return false, err
+			}
+
+			if slices.Contains(resolved, file) {
+				return true, fmt.Errorf(
+					"%q is pinned by its sha256 hash in %q and cannot be modified, remove or update the pin first",
+					filepath.ToSlash(file.Path()),
+					filepath.ToSlash(from.Path()),
+				)
+			}
+		}
+
+		return false, nil
+	})
+
+	return err
+}
+
+// readTrust reads the trust database at path, which may not exist yet.
+func readTrust(path string) (trustFile, error) {
+	var trust trustFile
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return trust, nil
+	}
+
+	if err != nil {
+		return trust, fmt.Errorf("reading trust database %q: %w", filepath.ToSlash(path), err)
+	}
+
+	if err := yaml.Unmarshal(data, &trust); err != nil {
+		return trust, fmt.Errorf("unmarshalling trust database %q: %w", filepath.ToSlash(path), err)
+	}
+
+	return trust, nil
+}
+
+// hash returns the hex-encoded SHA-256 hash of data.
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
